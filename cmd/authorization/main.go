@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 )
 
 // @title Authorization service
@@ -48,13 +49,31 @@ func main() {
 		return
 	}
 
-	core, err := usecase.GetCore(relationalDataBaseConfig, cacheDatabaseConfig, logger)
+	accessTokenConfig, err := configs.ReadAccessTokenConfig()
+	if err != nil {
+		logger.Error(variables.ReadAccessTokenConfigError, "error", err.Error())
+		return
+	}
+
+	signingKeys, err := usecase.NewSigningKeys(accessTokenConfig, logger)
+	if err != nil {
+		logger.Error(variables.SigningKeysError, "error", err.Error())
+		return
+	}
+
+	var accessTokenTTL time.Duration
+	if accessTokenConfig.Enabled {
+		accessTokenTTL = time.Duration(accessTokenConfig.TTLMinutes) * time.Minute
+	}
+	revocations := usecase.NewRevocations(accessTokenTTL, logger)
+
+	core, err := usecase.GetCore(relationalDataBaseConfig, cacheDatabaseConfig, signingKeys, revocations, logger)
 	if err != nil {
 		logger.Error(variables.CoreInitializeError, err)
 		return
 	}
 
-	grpcServer, err := delivery_grpc.NewServer(relationalDataBaseConfig, cacheDatabaseConfig, logger)
+	grpcServer, err := delivery_grpc.NewServer(relationalDataBaseConfig, cacheDatabaseConfig, signingKeys, revocations, logger)
 	if err != nil {
 		logger.Error(variables.ListenAndServeError)
 		return
@@ -42,7 +42,13 @@ func main() {
 		logger.Error(variables.ReadGrpcConfigError, err.Error())
 		return
 	}
-	core := usecase.GetCore(*grpcConfig, bannersRepository, logger)
+
+	retentionConfig, err := configs.ReadBannersRetentionConfig()
+	if err != nil {
+		logger.Error(variables.ReadRetentionConfigError, "error", err.Error())
+		return
+	}
+	core := usecase.GetCore(*grpcConfig, *retentionConfig, bannersRepository, logger)
 
 	api := delivery.GetApi(core, logger)
 
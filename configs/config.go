@@ -42,6 +42,10 @@ func ReadAuthAppConfig() (*variables.AppConfig, error) {
 	return ParseFlagsAndReadYAMLFile[variables.AppConfig]("auth_config_path", "../../configs/AuthorizationAppConfig.yml", flag.CommandLine)
 }
 
+func ReadAccessTokenConfig() (*variables.AccessTokenConfig, error) {
+	return ParseFlagsAndReadYAMLFile[variables.AccessTokenConfig]("access_token_config_path", "../../configs/AuthorizationAccessTokenConfig.yml", flag.CommandLine)
+}
+
 func ReadGrpcConfig() (*variables.GrpcConfig, error) {
 	return ParseFlagsAndReadYAMLFile[variables.GrpcConfig]("grpc_config_path", "../../configs/GrpcConfig.yml", flag.CommandLine)
 }
@@ -58,6 +62,10 @@ func ReadRelationalBannersDataBaseConfig() (*variables.RelationalDataBaseConfig,
 	return ParseFlagsAndReadYAMLFile[variables.RelationalDataBaseConfig]("sql_config_films_path", "../../configs/BannersSqlDataBaseConfig.yml", flag.CommandLine)
 }
 
+func ReadBannersRetentionConfig() (*variables.VersionRetentionConfig, error) {
+	return ParseFlagsAndReadYAMLFile[variables.VersionRetentionConfig]("retention_config_path", "../../configs/BannersRetentionConfig.yml", flag.CommandLine)
+}
+
 func ReadCacheDatabaseConfig() (*variables.CacheDataBaseConfig, error) {
 	return ParseFlagsAndReadYAMLFile[variables.CacheDataBaseConfig]("cache_config_path", "../../configs/AuthorizationCacheDataBaseConfig.yml", flag.CommandLine)
 }
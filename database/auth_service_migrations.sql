@@ -3,6 +3,9 @@ ALTER TABLE profile_role
     DROP CONSTRAINT IF EXISTS fk_profile,
     DROP CONSTRAINT IF EXISTS fk_role;
 
+-- Удаление таблицы api_token
+DROP TABLE IF EXISTS api_token;
+
 -- Удаление таблицы profile
 DROP TABLE IF EXISTS profile;
 
@@ -38,6 +41,19 @@ CREATE TABLE profile (
                          CONSTRAINT fk_profile_role FOREIGN KEY (profile_role_id) REFERENCES profile_role (id)
 );
 
+-- Создание таблицы api_token: хранится только хэш токена
+CREATE TABLE api_token (
+                           id SERIAL PRIMARY KEY,
+                           profile_id INT NOT NULL REFERENCES profile (id) ON DELETE CASCADE,
+                           name TEXT NOT NULL,
+                           token_hash BYTEA NOT NULL UNIQUE,
+                           scopes TEXT[] NOT NULL DEFAULT '{}',
+                           created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+                           revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX api_token_profile_idx ON api_token (profile_id);
+
 -- Создание таблицы role
 CREATE TABLE role (
                       id SERIAL PRIMARY KEY,
@@ -1,3 +1,4 @@
+DROP TABLE IF EXISTS banner_stats;
 DROP TABLE IF EXISTS banner_tag;
 DROP TABLE IF EXISTS versions;
 DROP TABLE IF EXISTS banners;
@@ -6,7 +7,8 @@ DROP TABLE IF EXISTS tags;
 
 CREATE TABLE features (
                       id INTEGER PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
-                      name TEXT NOT NULL UNIQUE
+                      name TEXT NOT NULL UNIQUE,
+                      schema JSONB
 );
 
 CREATE TABLE tags (
@@ -18,13 +20,22 @@ CREATE TABLE banners (
                       id SERIAL PRIMARY KEY,
                       created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
                       feature_id INTEGER,
-                      FOREIGN KEY (feature_id) REFERENCES features(id)
+                      is_active BOOLEAN NOT NULL DEFAULT TRUE,
+                      starts_at TIMESTAMP WITH TIME ZONE,
+                      ends_at TIMESTAMP WITH TIME ZONE,
+                      FOREIGN KEY (feature_id) REFERENCES features(id),
+                      CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at),
+                      UNIQUE (id, feature_id)
 );
 
+-- feature_id дублирует banners.feature_id, чтобы пара (фича, тег) была уникальной
 CREATE TABLE banner_tag (
-                      banner_id INTEGER REFERENCES banners ON DELETE CASCADE,
+                      banner_id INTEGER NOT NULL,
+                      feature_id INTEGER NOT NULL,
                       tag_id INTEGER REFERENCES tags ON DELETE CASCADE,
-                      PRIMARY KEY (banner_id, tag_id)
+                      PRIMARY KEY (banner_id, tag_id),
+                      FOREIGN KEY (banner_id, feature_id) REFERENCES banners (id, feature_id) ON DELETE CASCADE ON UPDATE CASCADE,
+                      UNIQUE (feature_id, tag_id)
 );
 
 CREATE TABLE versions (
@@ -32,9 +43,24 @@ CREATE TABLE versions (
                       banner_id INTEGER REFERENCES banners ON DELETE CASCADE,
                       is_active BOOLEAN DEFAULT TRUE,
                       data JSONB NOT NULL,
+                      variants JSONB,
                       updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Индексы для сортировки, курсорной пагинации и поиска по содержимому баннеров
+CREATE INDEX banners_created_at_idx ON banners (created_at, id);
+CREATE INDEX versions_active_updated_at_idx ON versions (updated_at, banner_id) WHERE is_active;
+CREATE INDEX versions_active_data_search_idx ON versions USING GIN (jsonb_to_tsvector('simple', data, '["string"]')) WHERE is_active;
+CREATE INDEX versions_active_data_path_idx ON versions USING GIN (data jsonb_path_ops) WHERE is_active;
+
+CREATE TABLE banner_stats (
+                      banner_id INTEGER REFERENCES banners ON DELETE CASCADE,
+                      day DATE NOT NULL,
+                      impressions BIGINT NOT NULL DEFAULT 0,
+                      clicks BIGINT NOT NULL DEFAULT 0,
+                      PRIMARY KEY (banner_id, day)
+);
+
 INSERT INTO features (name) VALUES
                                 ('Feature 1'),
                                 ('Feature 2'),
@@ -50,11 +76,11 @@ INSERT INTO banners (id, created_at, feature_id) VALUES
                                                                 (2, NOW(), 2),
                                                                 (3, NOW(), 3);
 
-INSERT INTO banner_tag (banner_id, tag_id) VALUES
-                                               (1, 1),
-                                               (1, 2),
-                                               (2, 2),
-                                               (3, 3);
+INSERT INTO banner_tag (banner_id, feature_id, tag_id) VALUES
+                                               (1, 1, 1),
+                                               (1, 1, 2),
+                                               (2, 2, 2),
+                                               (3, 3, 3);
 
 INSERT INTO versions (banner_id, data, is_active, updated_at) VALUES
                                                        (2, '{"content": "Banner 2"}', TRUE,NOW()),
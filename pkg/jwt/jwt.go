@@ -0,0 +1,103 @@
+package jwt
+
+import (
+	"avito-track/pkg/variables"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// Claims are carried by access tokens. Scopes are only set for tokens issued
+// to a restricted API token, CredentialID names the session or API token the
+// access token was issued for, so it can be revoked with it.
+type Claims struct {
+	CredentialID string   `json:"sid"`
+	UserID       int64    `json:"uid"`
+	Role         string   `json:"role"`
+	Scopes       []string `json:"scopes,omitempty"`
+	IssuedAt     int64    `json:"iat"`
+	ExpiresAt    int64    `json:"exp"`
+}
+
+// KeyFunc returns the public key with the given id.
+type KeyFunc func(keyID string) (ed25519.PublicKey, error)
+
+// IsToken reports whether the credential has the shape of a JWT. Session ids
+// and API tokens never contain dots.
+func IsToken(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+func Sign(claims Claims, keyID string, key ed25519.PrivateKey) (string, error) {
+	rawHeader, err := json.Marshal(header{Algorithm: variables.JWTAlgorithm, Type: variables.JWTType, KeyID: keyID})
+	if err != nil {
+		return "", err
+	}
+
+	rawClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(rawHeader) + "." + base64.RawURLEncoding.EncodeToString(rawClaims)
+	signature := ed25519.Sign(key, []byte(signed))
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Parse verifies the signature of the token with the key named in its header
+// and returns its claims, unless the token has expired.
+func Parse(token string, keys KeyFunc) (Claims, error) {
+	rawHeader, rest, _ := strings.Cut(token, ".")
+	rawClaims, rawSignature, found := strings.Cut(rest, ".")
+	if !found {
+		return Claims{}, variables.ErrInvalidJWT
+	}
+
+	var tokenHeader header
+	err := decodeSegment(rawHeader, &tokenHeader)
+	if err != nil || tokenHeader.Algorithm != variables.JWTAlgorithm {
+		return Claims{}, variables.ErrInvalidJWT
+	}
+
+	key, err := keys(tokenHeader.KeyID)
+	if err != nil {
+		return Claims{}, err
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return Claims{}, variables.ErrUnknownJWTKey
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(rawSignature)
+	if err != nil || !ed25519.Verify(key, []byte(token[:len(rawHeader)+1+len(rawClaims)]), signature) {
+		return Claims{}, variables.ErrInvalidJWT
+	}
+
+	var claims Claims
+	err = decodeSegment(rawClaims, &claims)
+	if err != nil || claims.UserID == 0 {
+		return Claims{}, variables.ErrInvalidJWT
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return Claims{}, variables.ErrExpiredJWT
+	}
+
+	return claims, nil
+}
+
+func decodeSegment(segment string, value any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, value)
+}
@@ -0,0 +1,49 @@
+package jwt
+
+import (
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// CredentialID names the credential an access token was issued for, as stored
+// in its sid claim.
+func CredentialID(credentialHash []byte) string {
+	return base64.RawURLEncoding.EncodeToString(credentialHash)
+}
+
+// RevocationList remembers ended credentials until the access tokens issued
+// for them have expired.
+type RevocationList struct {
+	mutex   sync.Mutex
+	entries map[string]time.Time
+}
+
+func NewRevocationList() *RevocationList {
+	return &RevocationList{entries: make(map[string]time.Time)}
+}
+
+func (list *RevocationList) Add(credentialID string, until time.Time) {
+	now := time.Now()
+	if !now.Before(until) {
+		return
+	}
+
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	for id, expiresAt := range list.entries {
+		if !now.Before(expiresAt) {
+			delete(list.entries, id)
+		}
+	}
+	list.entries[credentialID] = until
+}
+
+func (list *RevocationList) Contains(credentialID string) bool {
+	list.mutex.Lock()
+	until, found := list.entries[credentialID]
+	list.mutex.Unlock()
+
+	return found && time.Now().Before(until)
+}
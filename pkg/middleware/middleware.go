@@ -1,16 +1,21 @@
 package middleware
 
 import (
+	"avito-track/pkg/jwt"
+	"avito-track/pkg/models"
 	"avito-track/pkg/util"
 	"avito-track/pkg/variables"
 	"context"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 )
 
 type ICore interface {
-	GetUserId(ctx context.Context, sid string) (int64, error)
+	Authenticate(ctx context.Context, sid string) (models.Identity, error)
 	GetUserRole(ctx context.Context, id int64) (string, error)
+	VerifyAccessToken(token string) (jwt.Claims, error)
 }
 
 func MethodMiddleware(next http.Handler, methods []string, logger *slog.Logger) http.Handler {
@@ -32,20 +37,51 @@ func MethodMiddleware(next http.Handler, methods []string, logger *slog.Logger)
 }
 
 func AuthorizationMiddleware(next http.Handler, core ICore, logger *slog.Logger) http.Handler {
+	return authorize(next, core, true, logger)
+}
+
+// IssuerAuthorizationMiddleware authenticates the request like
+// AuthorizationMiddleware but leaves the scopes to the handler. It is meant for
+// endpoints that hand the scopes on to a credential derived from the request.
+func IssuerAuthorizationMiddleware(next http.Handler, core ICore, logger *slog.Logger) http.Handler {
+	return authorize(next, core, false, logger)
+}
+
+func authorize(next http.Handler, core ICore, checkScopes bool, logger *slog.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		session, err := r.Cookie(variables.SessionCookieName)
-		if err != nil {
+		sid, found := Credentials(r)
+		if !found {
 			util.SendResponse(w, r, http.StatusUnauthorized, nil, variables.StatusUnauthorizedError, nil, logger)
 			return
 		}
 
-		userId, err := core.GetUserId(r.Context(), session.Value)
-		if err != nil || userId == 0 {
+		var identity models.Identity
+		var err error
+		if jwt.IsToken(sid) {
+			var claims jwt.Claims
+			claims, err = core.VerifyAccessToken(sid)
+			identity = models.Identity{UserID: claims.UserID, Roles: []string{claims.Role}, Scopes: claims.Scopes}
+		} else {
+			identity, err = core.Authenticate(r.Context(), sid)
+		}
+		if err != nil || identity.UserID == 0 {
 			util.SendResponse(w, r, http.StatusUnauthorized, nil, variables.StatusUnauthorizedError, nil, logger)
 			return
 		}
 
-		r = r.WithContext(context.WithValue(r.Context(), variables.UserIDKey, userId))
+		if checkScopes && !scopesAllow(r.Method, identity.Scopes) {
+			util.SendResponse(w, r, http.StatusForbidden, nil, variables.StatusForbiddenError, nil, logger)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), variables.UserIDKey, identity.UserID)
+		if role := util.PrimaryRole(identity.Roles); role != "" {
+			ctx = context.WithValue(ctx, variables.RoleKey, role)
+		}
+		if len(identity.Scopes) > 0 {
+			ctx = context.WithValue(ctx, variables.ScopesKey, identity.Scopes)
+		}
+		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -58,12 +94,18 @@ func PermissionsMiddleware(next http.Handler, core ICore, roles []string, logger
 			return
 		}
 
-		userRole, err := core.GetUserRole(r.Context(), userId)
-		if err != nil {
-			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, logger)
-			return
+		// The role is resolved with the credential, it is only looked up when
+		// the user has none.
+		userRole, found := r.Context().Value(variables.RoleKey).(string)
+		if !found {
+			var err error
+			userRole, err = core.GetUserRole(r.Context(), userId)
+			if err != nil {
+				util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, logger)
+				return
+			}
+			r = r.WithContext(context.WithValue(r.Context(), variables.RoleKey, userRole))
 		}
-		r = r.WithContext(context.WithValue(r.Context(), variables.RoleKey, userRole))
 
 		isPermitted := false
 		for _, val := range roles {
@@ -81,3 +123,37 @@ func PermissionsMiddleware(next http.Handler, core ICore, roles []string, logger
 		next.ServeHTTP(w, r)
 	})
 }
+
+// Credentials returns the session id the request authenticates with. The
+// Authorization header wins over the token header, which wins over the session
+// cookie; a present but malformed header is not replaced by the next source.
+func Credentials(r *http.Request) (string, bool) {
+	if authorization := r.Header.Get(variables.AuthorizationHeader); authorization != "" {
+		scheme, token, found := strings.Cut(authorization, " ")
+		if !found || !strings.EqualFold(scheme, variables.BearerScheme) {
+			return "", false
+		}
+		token = strings.TrimSpace(token)
+		return token, token != ""
+	}
+
+	if token := r.Header.Get(variables.TokenHeader); token != "" {
+		return token, true
+	}
+
+	session, err := r.Cookie(variables.SessionCookieName)
+	if err != nil || session.Value == "" {
+		return "", false
+	}
+	return session.Value, true
+}
+
+// scopesAllow reports whether a credential with the given scopes may use the
+// method. Credentials without scopes are not restricted, read-only ones are
+// limited to safe methods.
+func scopesAllow(method string, scopes []string) bool {
+	if len(scopes) == 0 || slices.Contains(scopes, variables.ScopeBannersWrite) {
+		return true
+	}
+	return method == http.MethodGet || method == http.MethodHead
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"avito-track/pkg/variables"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCredentials(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		token         string
+		cookie        string
+		want          string
+		wantFound     bool
+	}{
+		{name: "none"},
+		{name: "cookie", cookie: "from-cookie", want: "from-cookie", wantFound: true},
+		{name: "token header", token: "from-token", want: "from-token", wantFound: true},
+		{name: "bearer", authorization: "Bearer from-bearer", want: "from-bearer", wantFound: true},
+		{name: "bearer any case", authorization: "bearer  from-bearer ", want: "from-bearer", wantFound: true},
+		{name: "token header over cookie", token: "from-token", cookie: "from-cookie", want: "from-token", wantFound: true},
+		{name: "bearer over cookie", authorization: "Bearer from-bearer", cookie: "from-cookie", want: "from-bearer", wantFound: true},
+		{name: "bearer over token header", authorization: "Bearer from-bearer", token: "from-token", want: "from-bearer", wantFound: true},
+		{name: "bearer over both", authorization: "Bearer from-bearer", token: "from-token", cookie: "from-cookie", want: "from-bearer", wantFound: true},
+		{name: "other scheme", authorization: "Basic dXNlcjpwYXNz", cookie: "from-cookie"},
+		{name: "scheme only", authorization: "Bearer", token: "from-token"},
+		{name: "empty bearer", authorization: "Bearer  ", cookie: "from-cookie"},
+		{name: "empty cookie", cookie: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/user_banner", nil)
+			if test.authorization != "" {
+				r.Header.Set(variables.AuthorizationHeader, test.authorization)
+			}
+			if test.token != "" {
+				r.Header.Set(variables.TokenHeader, test.token)
+			}
+			if test.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: variables.SessionCookieName, Value: test.cookie})
+			}
+
+			got, found := Credentials(r)
+			if got != test.want || found != test.wantFound {
+				t.Errorf("Credentials() = %q, %v, want %q, %v", got, found, test.want, test.wantFound)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"time"
+)
 
 type (
 	Session struct {
@@ -13,13 +17,197 @@ type (
 		Login string `json:"login"`
 	}
 
+	// AuthCacheStats reports how the authentication cache of the banners
+	// service performs. Negative hits are lookups of rejected credentials.
+	AuthCacheStats struct {
+		Hits          uint64  `json:"hits"`
+		NegativeHits  uint64  `json:"negative_hits"`
+		Misses        uint64  `json:"misses"`
+		Evictions     uint64  `json:"evictions"`
+		Invalidations uint64  `json:"invalidations"`
+		Size          int     `json:"size"`
+		HitRate       float64 `json:"hit_rate"`
+	}
+
+	// Revocation reports an ended session or API token. Access tokens issued
+	// for it remain verifiable until AccessTokensUntil and must be rejected.
+	Revocation struct {
+		CredentialHash    []byte
+		AccessTokensUntil time.Time
+	}
+
+	// Identity is what a credential resolves to. ExpiresAt is nil for API
+	// tokens, which do not expire.
+	Identity struct {
+		UserID    int64
+		Login     string
+		Roles     []string
+		Scopes    []string
+		ExpiresAt *time.Time
+	}
+
+	// ApiToken describes a long-lived credential. The token itself is only
+	// known to its owner; the service keeps its hash.
+	ApiToken struct {
+		TokenID   int64      `json:"token_id"`
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		CreatedAt time.Time  `json:"created_at"`
+		RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	}
+
+	// VerificationKey is a public key access tokens are signed with. ExpiresAt
+	// is zero for the key currently used for signing.
+	VerificationKey struct {
+		KeyID     string            `json:"key_id"`
+		PublicKey ed25519.PublicKey `json:"public_key"`
+		ExpiresAt time.Time         `json:"expires_at"`
+	}
+
 	Banner struct {
-		BannerID  int64   `json:"banner_id"`
-		TagIDs    []int64 `json:"tag_ids"`
-		FeatureID int64   `json:"feature_id"`
-		Content   string  `json:"content"`
-		IsActive  bool    `json:"is_active"`
-		CreatedAt string  `json:"created_at"`
-		UpdatedAt string  `json:"updated_at"`
+		BannerID  int64           `json:"banner_id"`
+		VersionID int64           `json:"version_id,omitempty"`
+		TagIDs    []int64         `json:"tag_ids"`
+		FeatureID int64           `json:"feature_id"`
+		Content   json.RawMessage `json:"content"`
+		Variants  []BannerVariant `json:"variants,omitempty"`
+		Variant   string          `json:"variant,omitempty"`
+		IsActive  bool            `json:"is_active"`
+		StartsAt  *time.Time      `json:"starts_at"`
+		EndsAt    *time.Time      `json:"ends_at"`
+		Status    string          `json:"status,omitempty"`
+		CreatedAt string          `json:"created_at"`
+		UpdatedAt string          `json:"updated_at"`
+	}
+
+	// BannerFilter selects banners for listing. Zero fields leave their filter
+	// out; a banner matches TagIDs when it has any of them. Query is a JSON
+	// path when it starts with '$' and a full-text search otherwise.
+	BannerFilter struct {
+		FeatureID  int64
+		TagIDs     []int64
+		Status     string
+		Query      string
+		ActiveOnly bool
+	}
+
+	// BannerSort orders a banner list by Field, ties broken by id.
+	BannerSort struct {
+		Field      string
+		Descending bool
+	}
+
+	// BannerCursor points right after a banner of a list ordered by the update
+	// time of the current version and id, newest first.
+	BannerCursor struct {
+		UpdatedAt time.Time `json:"updated_at"`
+		BannerID  int64     `json:"banner_id"`
+	}
+
+	BannerPage struct {
+		Banners []Banner
+		Next    *BannerCursor
+		Total   *int64
+	}
+
+	// BannerVariant is one of the weighted creatives a banner shows instead of
+	// its content. Users are spread over variants in proportion to Weight.
+	BannerVariant struct {
+		Name    string          `json:"name"`
+		Weight  int64           `json:"weight"`
+		Content json.RawMessage `json:"content"`
+	}
+
+	// BannerPatch holds the fields of a partial banner update. Nil fields are
+	// left untouched.
+	BannerPatch struct {
+		TagIDs    *[]int64
+		FeatureID *int64
+		Content   *json.RawMessage
+		Variants  *[]BannerVariant
+		IsActive  *bool
+		StartsAt  NullableTime
+		EndsAt    NullableTime
+	}
+
+	BannerVersion struct {
+		VersionID int64           `json:"version_id"`
+		BannerID  int64           `json:"banner_id"`
+		Content   json.RawMessage `json:"content"`
+		Variants  []BannerVariant `json:"variants,omitempty"`
+		IsActive  bool            `json:"is_active"`
+		UpdatedAt string          `json:"updated_at"`
+	}
+
+	Feature struct {
+		FeatureID int64  `json:"feature_id"`
+		Name      string `json:"name"`
+	}
+
+	Tag struct {
+		TagID int64  `json:"tag_id"`
+		Name  string `json:"name"`
+	}
+
+	FieldError struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+
+	// ImportError describes why a row of a banner import was rejected. Row
+	// counts records from 1.
+	ImportError struct {
+		Row       int          `json:"row"`
+		Message   string       `json:"message"`
+		Fields    []FieldError `json:"fields,omitempty"`
+		BannerIDs []int64      `json:"banner_ids,omitempty"`
+		FeatureID int64        `json:"feature_id,omitempty"`
+		TagIDs    []int64      `json:"tag_ids,omitempty"`
+	}
+
+	ImportResult struct {
+		DryRun   bool          `json:"dry_run"`
+		Imported int           `json:"imported"`
+		Errors   []ImportError `json:"errors"`
+	}
+
+	BannerStats struct {
+		BannerID    int64  `json:"banner_id"`
+		Day         string `json:"day"`
+		Impressions int64  `json:"impressions"`
+		Clicks      int64  `json:"clicks"`
+	}
+
+	DeleteJob struct {
+		JobID     int64  `json:"job_id"`
+		FeatureID int64  `json:"feature_id,omitempty"`
+		TagID     int64  `json:"tag_id,omitempty"`
+		Status    string `json:"status"`
+		Total     int64  `json:"total"`
+		Deleted   int64  `json:"deleted"`
+		Error     string `json:"error,omitempty"`
 	}
 )
+
+// NullableTime tells a time left out of a patch from one explicitly set to
+// null. Set is true whenever the field is present.
+type NullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (nullable *NullableTime) UnmarshalJSON(data []byte) error {
+	nullable.Set = true
+	if string(data) == "null" {
+		nullable.Time = nil
+		return nil
+	}
+
+	var value time.Time
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+	nullable.Time = &value
+	return nil
+}
@@ -1,5 +1,11 @@
 package communication
 
+import (
+	"avito-track/pkg/models"
+	"encoding/json"
+	"time"
+)
+
 type (
 	SigninRequest struct {
 		Login    string `json:"login"`
@@ -12,8 +18,53 @@ type (
 	}
 
 	BannerRequest struct {
-		TagIds    []int64 `json:"tag_ids"`
-		FeatureId int64   `json:"feature_id"`
-		Content   string  `json:"content"`
+		TagIds    []int64                `json:"tag_ids"`
+		FeatureId int64                  `json:"feature_id"`
+		Content   json.RawMessage        `json:"content"`
+		Variants  []models.BannerVariant `json:"variants"`
+		IsActive  *bool                  `json:"is_active"`
+		StartsAt  *time.Time             `json:"starts_at"`
+		EndsAt    *time.Time             `json:"ends_at"`
+	}
+
+	TokenRequest struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+
+	// BannerRecord is a banner as it is exported and imported.
+	BannerRecord struct {
+		BannerId  int64                  `json:"banner_id"`
+		TagIds    []int64                `json:"tag_ids"`
+		FeatureId int64                  `json:"feature_id"`
+		Content   json.RawMessage        `json:"content"`
+		Variants  []models.BannerVariant `json:"variants,omitempty"`
+		IsActive  bool                   `json:"is_active"`
+		StartsAt  *time.Time             `json:"starts_at"`
+		EndsAt    *time.Time             `json:"ends_at"`
+	}
+
+	BannerPatchRequest struct {
+		TagIds    *[]int64                `json:"tag_ids"`
+		FeatureId *int64                  `json:"feature_id"`
+		Content   *json.RawMessage        `json:"content"`
+		Variants  *[]models.BannerVariant `json:"variants"`
+		IsActive  *bool                   `json:"is_active"`
+		StartsAt  models.NullableTime     `json:"starts_at"`
+		EndsAt    models.NullableTime     `json:"ends_at"`
+	}
+
+	NameRequest struct {
+		Name string `json:"name"`
+	}
+
+	RollbackRequest struct {
+		VersionId int64 `json:"version_id"`
+	}
+
+	ClickRequest struct {
+		BannerId  int64 `json:"banner_id"`
+		TagId     int64 `json:"tag_id"`
+		FeatureId int64 `json:"feature_id"`
 	}
 )
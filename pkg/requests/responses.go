@@ -1,7 +1,49 @@
 package communication
 
+import (
+	"avito-track/pkg/models"
+	"time"
+)
+
 type (
 	SignupResponse struct {
 		Login string `json:"login"`
 	}
+
+	TokenCreatedResponse struct {
+		Token string          `json:"token"`
+		Info  models.ApiToken `json:"info"`
+	}
+
+	AccessTokenResponse struct {
+		AccessToken string    `json:"access_token"`
+		TokenType   string    `json:"token_type"`
+		ExpiresAt   time.Time `json:"expires_at"`
+	}
+
+	BannerCreatedResponse struct {
+		BannerID int64 `json:"banner_id"`
+	}
+
+	BannerPageResponse struct {
+		Banners    []models.Banner `json:"banners"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+		Total      *int64          `json:"total,omitempty"`
+	}
+
+	ConflictResponse struct {
+		Error     string  `json:"error"`
+		BannerIDs []int64 `json:"banner_ids"`
+	}
+
+	UnknownReferencesResponse struct {
+		Error     string  `json:"error"`
+		FeatureID int64   `json:"feature_id,omitempty"`
+		TagIDs    []int64 `json:"tag_ids,omitempty"`
+	}
+
+	ValidationResponse struct {
+		Error  string              `json:"error"`
+		Fields []models.FieldError `json:"fields"`
+	}
 )
@@ -2,19 +2,41 @@ package util
 
 import (
 	"avito-track/pkg/variables"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
-	"math/rand"
+	mathrand "math/rand"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 )
 
-func SendResponse(w http.ResponseWriter, r *http.Request, status int, body any, errorMessage string, handlerError error, logger *slog.Logger) {
+// SendResponse writes body as JSON with the given status. Headers are added
+// to the response first; a 304 response is sent without a body.
+func SendResponse(w http.ResponseWriter, r *http.Request, status int, body any, errorMessage string, handlerError error, logger *slog.Logger, headers ...http.Header) {
+	for _, header := range headers {
+		for key, values := range header {
+			for _, value := range values {
+				w.Header().Add(key, value)
+			}
+		}
+	}
+
+	if status == http.StatusNotModified {
+		w.WriteHeader(status)
+		logger.Error(errorMessage, r.Method, strconv.Itoa(status), r.URL.Path, nil)
+		return
+	}
+
 	jsonResponse, err := json.Marshal(body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -53,6 +75,48 @@ func GetRequestBody(w http.ResponseWriter, r *http.Request, requestObject any, l
 	return nil
 }
 
+// IsJsonObject reports whether data holds a JSON object.
+func IsJsonObject(data json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '{' && json.Valid(trimmed)
+}
+
+// BannerStatus computes whether a banner with the given schedule is
+// scheduled, live or expired at the moment now.
+func BannerStatus(startsAt *time.Time, endsAt *time.Time, now time.Time) string {
+	if startsAt != nil && now.Before(*startsAt) {
+		return variables.BannerStatusScheduled
+	}
+	if endsAt != nil && !now.Before(*endsAt) {
+		return variables.BannerStatusExpired
+	}
+	return variables.BannerStatusLive
+}
+
+// CacheHeaders returns the headers letting clients cache a response tagged
+// with etag for maxAge. A zero maxAge makes clients revalidate every time.
+func CacheHeaders(etag string, maxAge time.Duration) http.Header {
+	header := make(http.Header)
+	header.Set("ETag", etag)
+	if maxAge > 0 {
+		header.Set("Cache-Control", "private, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	} else {
+		header.Set("Cache-Control", "private, no-cache")
+	}
+	return header
+}
+
+// ETagMatches reports whether the If-None-Match header value lists etag.
+func ETagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func GetCookie(name string, value string, path string, httpOnly bool, expires time.Time) *http.Cookie {
 	return &http.Cookie{
 		Name:     name,
@@ -66,17 +130,45 @@ func GetCookie(name string, value string, path string, httpOnly bool, expires ti
 func RandStringRunes(seed int) string {
 	symbols := make([]rune, seed)
 	for i := range symbols {
-		symbols[i] = variables.LetterRunes[rand.Intn(len(variables.LetterRunes))]
+		symbols[i] = variables.LetterRunes[mathrand.Intn(len(variables.LetterRunes))]
 	}
 	return string(symbols)
 }
 
+// PrimaryRole returns the role a user with several roles acts with, which is
+// admin whenever the user has it.
+func PrimaryRole(roles []string) string {
+	if slices.Contains(roles, variables.AdminRole[0]) {
+		return variables.AdminRole[0]
+	}
+	if len(roles) == 0 {
+		return ""
+	}
+	return roles[0]
+}
+
 func HashPassword(password string) []byte {
 	hashPassword := sha512.Sum512([]byte(password))
 	passwordByteSlice := hashPassword[:]
 	return passwordByteSlice
 }
 
+// NewApiToken returns a random API token. Only its owner receives the token,
+// the service stores HashToken of it.
+func NewApiToken() (string, error) {
+	raw := make([]byte, variables.ApiTokenBytes)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	return variables.ApiTokenPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func HashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
 func Pagination(r *http.Request) (uint64, uint64) {
 	page, err := strconv.ParseUint(r.URL.Query().Get(variables.PaginationPageNumber), 10, 64)
 	if err != nil {
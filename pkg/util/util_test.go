@@ -0,0 +1,56 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := `"1-2"`
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{name: "empty", ifNoneMatch: "", want: false},
+		{name: "same", ifNoneMatch: `"1-2"`, want: true},
+		{name: "other", ifNoneMatch: `"1-3"`, want: false},
+		{name: "weak", ifNoneMatch: `W/"1-2"`, want: true},
+		{name: "any", ifNoneMatch: "*", want: true},
+		{name: "list", ifNoneMatch: `"1-1", W/"1-2"`, want: true},
+		{name: "list without match", ifNoneMatch: `"1-1", "1-3"`, want: false},
+		{name: "unquoted", ifNoneMatch: "1-2", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ETagMatches(test.ifNoneMatch, etag)
+			if got != test.want {
+				t.Errorf("ETagMatches(%q, %q) = %v, want %v", test.ifNoneMatch, etag, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCacheHeaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxAge       time.Duration
+		cacheControl string
+	}{
+		{name: "cached", maxAge: 5 * time.Minute, cacheControl: "private, max-age=300"},
+		{name: "revalidated", maxAge: 0, cacheControl: "private, no-cache"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := CacheHeaders(`"1-2"`, test.maxAge)
+			if got := header.Get("ETag"); got != `"1-2"` {
+				t.Errorf("ETag = %q, want %q", got, `"1-2"`)
+			}
+			if got := header.Get("Cache-Control"); got != test.cacheControl {
+				t.Errorf("Cache-Control = %q, want %q", got, test.cacheControl)
+			}
+		})
+	}
+}
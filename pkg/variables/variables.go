@@ -1,6 +1,11 @@
 package variables
 
-import "net/http"
+import (
+	"avito-track/pkg/models"
+	"errors"
+	"net/http"
+	"time"
+)
 
 // Server Errors
 const (
@@ -26,18 +31,130 @@ const (
 	SessionNotFoundError        = "Session not found"
 	UserAlreadyExistsError      = "User already exists"
 	StatusForbiddenError        = "Forbidden"
+	StatusNotFoundError         = "Not found"
 	GrpcListenAndServeError     = "Failed grpc to listen and serve"
 	GrpcConnectError            = "Failed grpc to connect"
 	InvalidImageError           = "Invalid image"
 	ValidateStringError         = "Validate string error"
 	FeatureIdError              = "invalid or missing 'feature_id' parameter"
 	TagIdError                  = "invalid or missing 'tag_id' parameter"
+	DuplicateTagIdsError        = "'tag_ids' must not contain duplicates"
 	LastRevisionError           = "invalid value for 'use_last_revision' parameter"
 	BannerNotFoundError         = "Banner not found"
 	InvalidLimit                = "Limit must be a positive number"
 	InvalidOffset               = "Offset must be non-negative"
+	BannerVersionsError         = "Get banner versions failed"
+	BannerRollbackError         = "Rollback banner failed"
+	VersionNotFoundError        = "Version not found"
+	VersionIdError              = "invalid or missing 'version_id' parameter"
+	DeleteFilterError           = "at least one of 'feature_id' and 'tag_id' parameters is required"
+	DeleteJobNotFoundError      = "Delete job not found"
+	DeleteJobIdError            = "invalid or missing job id"
+	BannerConflictError         = "Banner for this feature and tag already exists"
+	ContentError                = "'content' must be a JSON object"
+	ContentValidationError      = "'content' does not match the feature schema"
+	FeatureNotFoundError        = "Feature not found"
+	FeatureSchemaNotFoundError  = "Feature schema not found"
+	InvalidSchemaError          = "Invalid JSON Schema"
+	FeatureSchemaError          = "Feature schema request failed"
+	FeatureExistsError          = "Feature already exists"
+	FeatureInUseError           = "Feature is used by banners"
+	FeatureRequestError         = "Feature request failed"
+	TagNotFoundError            = "Tag not found"
+	TagExistsError              = "Tag already exists"
+	TagInUseError               = "Tag is used by banners"
+	TagRequestError             = "Tag request failed"
+	NameError                   = "invalid or missing 'name' parameter"
+	UnknownReferencesError      = "Unknown feature or tag"
+	ScheduleError               = "'ends_at' must be after 'starts_at'"
+	BannerStatusError           = "invalid value for 'status' parameter"
+	BannerIdError               = "invalid or missing 'banner_id' parameter"
+	StatsDateError              = "invalid value for 'from' or 'to' parameter"
+	BannerStatsError            = "Get banner stats failed"
+	TransferFormatError         = "invalid value for 'format' parameter"
+	DryRunError                 = "invalid value for 'dry_run' parameter"
+	ImportHeaderError           = "CSV header must contain 'feature_id', 'tag_ids' and 'content' columns"
+	ImportRecordError           = "Record is not a valid banner"
+	ImportRowsError             = "Import contains invalid rows"
+	ImportBannersError          = "Import banners failed"
+	ExportBannersError          = "Export banners failed"
+	CursorError                 = "invalid value for 'cursor' parameter"
+	WithTotalError              = "invalid value for 'with_total' parameter"
+	SortError                   = "invalid value for 'sort' or 'order' parameter"
+	SortCursorError             = "'sort' and 'order' parameters can not be combined with 'cursor'"
+	SearchQueryError            = "invalid value for 'q' parameter"
+	TokenNotFoundError          = "API token not found"
+	TokenIdError                = "invalid or missing token id"
+	TokenScopeError             = "unknown value in 'scopes'"
+	IssueTokenError             = "Issue API token failed"
+	TokenRequestError           = "API token request failed"
+	AccessTokenInvalidError     = "Access token is invalid"
+	AccessTokenExpiredError     = "Access token expired"
+	AccessTokenRevokedError     = "Access token was revoked with its session"
+	AccessTokenKeyError         = "Access token is signed with an unknown key"
+	AccessTokenDisabledError    = "Access tokens are disabled"
+	AccessTokenRefreshError     = "Access token can not be issued for another access token"
+	IssueAccessTokenError       = "Issue access token failed"
+	SigningKeysError            = "Signing keys request failed"
+	RevocationDroppedMessage    = "Revocation dropped for a slow subscriber"
+	WatchRevocationsError       = "Watch revocations failed"
+	VariantsError               = "'variants' must have unique non-empty names, positive weights and JSON object content"
+)
+
+// Errors
+var (
+	ErrBannerNotFound    = errors.New(BannerNotFoundError)
+	ErrVersionNotFound   = errors.New(VersionNotFoundError)
+	ErrDeleteJobNotFound = errors.New(DeleteJobNotFoundError)
+	ErrFeatureNotFound   = errors.New(FeatureNotFoundError)
+	ErrInvalidSchema     = errors.New(InvalidSchemaError)
+	ErrFeatureExists     = errors.New(FeatureExistsError)
+	ErrFeatureInUse      = errors.New(FeatureInUseError)
+	ErrTagNotFound       = errors.New(TagNotFoundError)
+	ErrTagExists         = errors.New(TagExistsError)
+	ErrTagInUse          = errors.New(TagInUseError)
+	ErrInvalidSchedule   = errors.New(ScheduleError)
+	ErrInvalidQuery      = errors.New(SearchQueryError)
+	ErrTokenNotFound     = errors.New(TokenNotFoundError)
+	ErrSessionNotFound   = errors.New(SessionNotFoundError)
+	ErrInvalidJWT        = errors.New(AccessTokenInvalidError)
+	ErrExpiredJWT        = errors.New(AccessTokenExpiredError)
+	ErrRevokedJWT        = errors.New(AccessTokenRevokedError)
+	ErrUnknownJWTKey     = errors.New(AccessTokenKeyError)
+	ErrJWTDisabled       = errors.New(AccessTokenDisabledError)
 )
 
+// BannerConflict is returned when a banner would share a (feature, tag) pair
+// with other banners.
+type BannerConflict struct {
+	BannerIDs []int64
+}
+
+func (conflict *BannerConflict) Error() string {
+	return BannerConflictError
+}
+
+// ContentValidation is returned when banner content does not match the JSON
+// Schema of its feature.
+type ContentValidation struct {
+	Fields []models.FieldError
+}
+
+func (validation *ContentValidation) Error() string {
+	return ContentValidationError
+}
+
+// UnknownReferences is returned when a banner refers to a feature or tags that
+// do not exist. FeatureID is zero when the feature is known.
+type UnknownReferences struct {
+	FeatureID int64
+	TagIDs    []int64
+}
+
+func (references *UnknownReferences) Error() string {
+	return UnknownReferencesError
+}
+
 // Middleware types
 type (
 	contextKey string
@@ -47,6 +164,7 @@ type (
 // Middleware keys constants
 const (
 	UserIDKey contextKey = "userId"
+	ScopesKey contextKey = "scopes"
 	RoleKey   roleKey    = "role"
 )
 
@@ -74,6 +192,18 @@ type (
 		Timer        uint32 `yaml:"timer"`
 	}
 
+	VersionRetentionConfig struct {
+		KeepLast    int    `yaml:"keep_last"`
+		MaxAgeHours int    `yaml:"max_age_hours"`
+		Timer       uint32 `yaml:"timer"`
+	}
+
+	AccessTokenConfig struct {
+		Enabled       bool `yaml:"enabled"`
+		TTLMinutes    int  `yaml:"ttl_minutes"`
+		RotationHours int  `yaml:"rotation_hours"`
+	}
+
 	GrpcConfig struct {
 		Address        string `yaml:"address"`
 		Port           string `yaml:"port"`
@@ -87,6 +217,49 @@ const (
 	HttpOnly          = true
 )
 
+// SessionLogIDLength is how many bytes of the session id hash identify a
+// session in the logs.
+const SessionLogIDLength = 8
+
+// API tokens. A token carrying scopes is limited to them, one without scopes
+// acts with every permission of its owner.
+const (
+	ApiTokenPrefix    = "at_"
+	ApiTokenBytes     = 32
+	ScopeBannersRead  = "banners:read"
+	ScopeBannersWrite = "banners:write"
+)
+
+var ApiTokenScopes = []string{ScopeBannersRead, ScopeBannersWrite}
+
+// Access tokens are JWTs signed with Ed25519. Services fetch the public keys
+// from the authorization service and verify tokens without calling it.
+const (
+	JWTAlgorithm            = "EdDSA"
+	JWTType                 = "JWT"
+	SigningKeysRefresh      = 5 * time.Minute
+	SigningKeysMinRefresh   = 10 * time.Second
+	SigningKeysTimeout      = 3 * time.Second
+	AccessTokenResponseType = "Bearer"
+)
+
+// Authentication cache constants. Entries are dropped when the authorization
+// service reports a logout, the TTL bounds staleness when a report is missed.
+const (
+	AuthCacheSize              = 10000
+	AuthCacheTTL               = 5 * time.Second
+	AuthCacheNegativeTTL       = 2 * time.Second
+	RevocationsBufferSize      = 256
+	RevocationsReconnectPeriod = 5 * time.Second
+)
+
+// Credential headers
+const (
+	AuthorizationHeader = "Authorization"
+	BearerScheme        = "Bearer"
+	TokenHeader         = "token"
+)
+
 // Repository messages
 const (
 	AuthorizationCachePingRetryError      = "Authorization cache: ping failed"
@@ -99,6 +272,19 @@ const (
 	FindProfileIdByLoginError             = "Find profile id by login failed:"
 	ProfileIdNotFoundByLoginError         = "Profile id not found:"
 	ProfileRoleNotFoundByLoginError       = "Profile role not found:"
+	SqlTokenCreateError                   = "API token create failed:"
+	SqlTokenListError                     = "API token list failed:"
+	SqlTokenRevokeError                   = "API token revoke failed:"
+	FindTokenOwnerError                   = "Find API token owner failed:"
+	FindProfileIdentityError              = "Find profile identity failed:"
+)
+
+// Postgres error codes
+const (
+	ForeignKeyViolationCode = "23503"
+	UniqueViolationCode     = "23505"
+	CheckViolationCode      = "23514"
+	SyntaxErrorCode         = "42601"
 )
 
 // Repository constants
@@ -107,6 +293,67 @@ const (
 	UserRoleId  = 1
 	AdminRoleId = 2
 	PageSize    = 10
+	// MaxPageSize caps the limit of list requests, larger limits are lowered
+	// to it.
+	MaxPageSize = 100
+)
+
+// Banner list sorting
+const (
+	BannerSortID        = "id"
+	BannerSortCreatedAt = "created_at"
+	BannerSortUpdatedAt = "updated_at"
+	SortOrderAsc        = "asc"
+	SortOrderDesc       = "desc"
+)
+
+// Banner schedule statuses
+const (
+	BannerStatusScheduled = "scheduled"
+	BannerStatusLive      = "live"
+	BannerStatusExpired   = "expired"
+)
+
+// Delete jobs constants
+const (
+	DeleteBatchSize  = 100
+	DeleteBatchPause = 200 * time.Millisecond
+	DeleteJobTTL     = time.Hour
+
+	DeleteJobStatusPending = "pending"
+	DeleteJobStatusRunning = "running"
+	DeleteJobStatusDone    = "done"
+	DeleteJobStatusFailed  = "failed"
+)
+
+// DefaultRetentionTimer is the pruning interval, in seconds, used when the
+// retention config does not set one.
+const DefaultRetentionTimer = 3600
+
+// Banner cache constants
+const (
+	BannerCacheSize            = 10000
+	BannerCacheTTL             = 5 * time.Minute
+	BannerCacheNegativeTTL     = 10 * time.Second
+	BannerCacheCleanupInterval = time.Minute
+)
+
+// Banner import and export constants
+const (
+	TransferFormatNDJSON = "ndjson"
+	TransferFormatCSV    = "csv"
+	MaxImportSize        = 32 << 20
+)
+
+// BannerCSVHeader lists the columns of banner CSV exports. Imports accept them
+// in any order.
+var BannerCSVHeader = []string{"banner_id", "feature_id", "tag_ids", "content", "variants", "is_active", "starts_at", "ends_at"}
+
+// Banner stats constants
+const (
+	StatsBufferSize    = 10000
+	StatsFlushInterval = 10 * time.Second
+	StatsDateLayout    = "2006-01-02"
 )
 
 // Core Messages
@@ -120,6 +367,13 @@ const (
 	GetProfileRoleError             = "Get profile role failed"
 	GrpcRecievError                 = "gRPC recieve error"
 	CannotCreateBanner              = "Can not create banner"
+	PruneVersionsError              = "Prune banner versions failed"
+	VersionsPrunedMessage           = "Banner versions pruned"
+	CountBannersError               = "Count banners failed"
+	DeleteBannersBatchError         = "Delete banners batch failed"
+	FlushStatsError                 = "Flush banner stats failed"
+	BannerCursorError               = "Build banner cursor failed"
+	StatsEventsDroppedMessage       = "Banner stats events dropped"
 )
 
 // Core variables
@@ -135,11 +389,13 @@ const (
 
 // Main messages
 const (
-	ReadAuthConfigError      = "Read auth config failed"
-	ReadAuthSqlConfigError   = "Read auth sql config failed"
-	ReadAuthCacheConfigError = "Read auth cache config failed"
-	ReadGrpcConfigError      = "Grpc config file error"
-	CoreInitializeError      = "Core initialize failed"
+	ReadAuthConfigError        = "Read auth config failed"
+	ReadAuthSqlConfigError     = "Read auth sql config failed"
+	ReadAuthCacheConfigError   = "Read auth cache config failed"
+	ReadGrpcConfigError        = "Grpc config file error"
+	ReadRetentionConfigError   = "Read retention config failed"
+	ReadAccessTokenConfigError = "Read access token config failed"
+	CoreInitializeError        = "Core initialize failed"
 )
 
 // Regexp
@@ -149,10 +405,14 @@ const (
 
 // Methods
 var (
-	MethodGet          = []string{http.MethodGet}
-	MethodPost         = []string{http.MethodPost}
-	MethodGetAndPost   = []string{http.MethodGet, http.MethodPost}
-	MethodsDeletePatch = []string{http.MethodDelete, http.MethodPatch}
+	MethodGet                 = []string{http.MethodGet}
+	MethodPost                = []string{http.MethodPost}
+	MethodGetAndPost          = []string{http.MethodGet, http.MethodPost}
+	MethodDelete              = []string{http.MethodDelete}
+	MethodsDeletePatch        = []string{http.MethodDelete, http.MethodPatch}
+	MethodsGetPostDelete      = []string{http.MethodGet, http.MethodPost, http.MethodDelete}
+	MethodsGetPostDeletePatch = []string{http.MethodGet, http.MethodPost, http.MethodDelete, http.MethodPatch}
+	MethodsGetPutDeletePatch  = []string{http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodPatch}
 )
 
 // Roles
@@ -168,6 +428,14 @@ const (
 	PaginationPageSize   = "page_size"
 )
 
+// Banner and feature sub-resources
+const (
+	BannerVersionsPath = "versions"
+	BannerRollbackPath = "rollback"
+	BannerStatsPath    = "stats"
+	FeatureSchemaPath  = "schema"
+)
+
 // Validate params
 var (
 	ValidImageTypes    = []string{"image/jpeg", "image/png", "image/gif"}
@@ -175,4 +443,6 @@ var (
 	MinTitleSize       = 5
 	MaxDescriptionSize = 900
 	MinDescriptionSize = 5
+	MaxNameSize        = 100
+	MinNameSize        = 1
 )
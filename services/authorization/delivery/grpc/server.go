@@ -2,15 +2,21 @@ package delivery_grpc
 
 import (
 	"avito-track/configs"
+	"avito-track/pkg/util"
 	"avito-track/pkg/variables"
 	pbAuth "avito-track/services/authorization/proto/authorization"
 	"avito-track/services/authorization/repository/profile"
 	"avito-track/services/authorization/repository/session"
+	"avito-track/services/authorization/usecase"
 	"context"
+	"errors"
 	"fmt"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"log/slog"
 	"net"
+	"strings"
 )
 
 type authorizationGrpc struct {
@@ -22,10 +28,12 @@ type authorizationGrpcServer struct {
 	pbAuth.UnimplementedAuthorizationServer
 	profileRepository *profile.ProfileRelationalRepository
 	sessionRepository *session.SessionCacheRepository
+	keys              *usecase.SigningKeys
+	revocations       *usecase.Revocations
 	logger            *slog.Logger
 }
 
-func NewServer(configRelational *variables.RelationalDataBaseConfig, configSession *variables.CacheDataBaseConfig, logger *slog.Logger) (*authorizationGrpc, error) {
+func NewServer(configRelational *variables.RelationalDataBaseConfig, configSession *variables.CacheDataBaseConfig, keys *usecase.SigningKeys, revocations *usecase.Revocations, logger *slog.Logger) (*authorizationGrpc, error) {
 	session, err := session.GetSessionRepository(configSession, logger)
 
 	if err != nil {
@@ -44,6 +52,8 @@ func NewServer(configRelational *variables.RelationalDataBaseConfig, configSessi
 		logger:            logger,
 		sessionRepository: session,
 		profileRepository: users,
+		keys:              keys,
+		revocations:       revocations,
 	})
 
 	return &authorizationGrpc{grpcServer: grpcServer, logger: logger}, nil
@@ -71,6 +81,17 @@ func (server *authorizationGrpc) ListenAndServeGrpc() error {
 }
 
 func (server *authorizationGrpcServer) GetId(ctx context.Context, req *pbAuth.FindIdRequest) (*pbAuth.FindIdResponse, error) {
+	if strings.HasPrefix(req.Sid, variables.ApiTokenPrefix) {
+		id, scopes, err := server.profileRepository.GetTokenOwner(util.HashToken(req.Sid))
+		if err != nil {
+			return nil, err
+		}
+		return &pbAuth.FindIdResponse{
+			Value:  id,
+			Scopes: scopes,
+		}, nil
+	}
+
 	login, err := server.sessionRepository.GetUserLogin(ctx, req.Sid, server.logger)
 	if err != nil {
 		return nil, err
@@ -97,3 +118,69 @@ func (server *authorizationGrpcServer) GetRole(ctx context.Context, req *pbAuth.
 		Role: role,
 	}, nil
 }
+
+func (server *authorizationGrpcServer) GetKeys(ctx context.Context, req *pbAuth.KeysRequest) (*pbAuth.KeysResponse, error) {
+	keys := server.keys.Verification()
+
+	response := &pbAuth.KeysResponse{Keys: make([]*pbAuth.VerificationKey, 0, len(keys))}
+	for _, key := range keys {
+		var expiresAt int64
+		if !key.ExpiresAt.IsZero() {
+			expiresAt = key.ExpiresAt.Unix()
+		}
+		response.Keys = append(response.Keys, &pbAuth.VerificationKey{
+			Id:        key.KeyID,
+			PublicKey: key.PublicKey,
+			ExpiresAt: expiresAt,
+		})
+	}
+
+	return response, nil
+}
+
+func (server *authorizationGrpcServer) Authenticate(ctx context.Context, req *pbAuth.AuthenticateRequest) (*pbAuth.AuthenticateResponse, error) {
+	identity, err := usecase.Authenticate(ctx, req.Sid, server.sessionRepository, server.profileRepository, server.logger)
+	if errors.Is(err, variables.ErrSessionNotFound) || errors.Is(err, variables.ErrTokenNotFound) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt int64
+	if identity.ExpiresAt != nil {
+		expiresAt = identity.ExpiresAt.Unix()
+	}
+
+	return &pbAuth.AuthenticateResponse{
+		Id:        identity.UserID,
+		Login:     identity.Login,
+		Roles:     identity.Roles,
+		ExpiresAt: expiresAt,
+		Scopes:    identity.Scopes,
+	}, nil
+}
+
+func (server *authorizationGrpcServer) WatchRevocations(req *pbAuth.RevocationsRequest, stream pbAuth.Authorization_WatchRevocationsServer) error {
+	events, unsubscribe := server.revocations.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case revocation := <-events:
+			var accessTokensUntil int64
+			if !revocation.AccessTokensUntil.IsZero() {
+				accessTokensUntil = revocation.AccessTokensUntil.Unix()
+			}
+			err := stream.Send(&pbAuth.Revocation{
+				CredentialHash:    revocation.CredentialHash,
+				AccessTokensUntil: accessTokensUntil,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
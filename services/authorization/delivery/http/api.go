@@ -1,6 +1,7 @@
 package delivery
 
 import (
+	"avito-track/pkg/jwt"
 	"avito-track/pkg/middleware"
 	"avito-track/pkg/models"
 	communication "avito-track/pkg/requests"
@@ -21,8 +22,13 @@ type ICore interface {
 	CreateUserAccount(login string, password string) error
 	FindUserByLogin(login string) (bool, error)
 	FindUserAccount(login string, password string) (*models.UserItem, bool, error)
-	GetUserId(ctx context.Context, sid string) (int64, error)
+	Authenticate(ctx context.Context, sid string) (models.Identity, error)
 	GetUserRole(ctx context.Context, id int64) (string, error)
+	IssueToken(profileID int64, name string, scopes []string) (string, models.ApiToken, error)
+	ListTokens(profileID int64) ([]models.ApiToken, error)
+	RevokeToken(profileID int64, tokenID int64) error
+	IssueAccessToken(ctx context.Context, userID int64, scopes []string, credentialHash []byte) (string, time.Time, error)
+	VerifyAccessToken(token string) (jwt.Claims, error)
 }
 
 type API struct {
@@ -67,6 +73,29 @@ func GetAuthorizationApi(authCore *usecase.Core, authLogger *slog.Logger) *API {
 		variables.MethodPost,
 		api.logger))
 
+	// Access token handler
+	api.mux.Handle("/access_token", middleware.MethodMiddleware(
+		middleware.IssuerAuthorizationMiddleware(
+			http.HandlerFunc(api.AccessToken),
+			api.core, api.logger),
+		variables.MethodPost,
+		api.logger))
+
+	// API tokens handlers
+	api.mux.Handle("/tokens", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			http.HandlerFunc(api.Tokens),
+			api.core, api.logger),
+		variables.MethodGetAndPost,
+		api.logger))
+
+	api.mux.Handle("/tokens/", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			http.HandlerFunc(api.RevokeToken),
+			api.core, api.logger),
+		variables.MethodDelete,
+		api.logger))
+
 	return api
 }
 
@@ -168,13 +197,13 @@ func (api *API) Signup(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {string} string variables.StatusInternalServerError
 // @Router /logout [post]
 func (api *API) LogoutSession(w http.ResponseWriter, r *http.Request) {
-	session, err := r.Cookie("session_id")
-	if err != nil {
+	sid, found := middleware.Credentials(r)
+	if !found {
 		util.SendResponse(w, r, http.StatusUnauthorized, variables.StatusUnauthorizedError, variables.SessionNotFoundError, nil, api.logger)
 		return
 	}
 
-	found, err := api.core.FindActiveSession(r.Context(), session.Value)
+	found, err := api.core.FindActiveSession(r.Context(), sid)
 	if err != nil {
 		util.SendResponse(w, r, http.StatusUnauthorized, nil, variables.StatusUnauthorizedError, err, api.logger)
 		return
@@ -185,13 +214,16 @@ func (api *API) LogoutSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = api.core.KillSession(r.Context(), session.Value)
+	err = api.core.KillSession(r.Context(), sid)
 	if err != nil {
 		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.SessionKilledError, err, api.logger)
 		return
 	}
 
-	session.Expires = time.Now().AddDate(0, 0, -1)
-	http.SetCookie(w, session)
+	session, err := r.Cookie(variables.SessionCookieName)
+	if err == nil && session.Value == sid {
+		session.Expires = time.Now().AddDate(0, 0, -1)
+		http.SetCookie(w, session)
+	}
 	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
 }
@@ -0,0 +1,154 @@
+package delivery
+
+import (
+	"avito-track/pkg/jwt"
+	"avito-track/pkg/middleware"
+	communication "avito-track/pkg/requests"
+	"avito-track/pkg/util"
+	"avito-track/pkg/variables"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// @Summary API tokens
+// @Tags tokens
+// @Description List API tokens of the user or issue a new one. The token is only returned on issue
+// @ID api-tokens
+// @Accept json
+// @Produce json
+// @Param input body communication.TokenRequest false "token name and scopes"
+// @Success 200 {array} models.ApiToken
+// @Success 201 {object} communication.TokenCreatedResponse
+// @Failure 400 {string} string variables.TokenScopeError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /tokens [get]
+// @Router /tokens [post]
+func (api *API) Tokens(w http.ResponseWriter, r *http.Request) {
+	userId, isAuth := r.Context().Value(variables.UserIDKey).(int64)
+	if !isAuth {
+		util.SendResponse(w, r, http.StatusUnauthorized, nil, variables.StatusUnauthorizedError, nil, api.logger)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tokens, err := api.core.ListTokens(userId)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, tokens, variables.StatusOkMessage, nil, api.logger)
+	case http.MethodPost:
+		var request communication.TokenRequest
+		err := util.GetRequestBody(w, r, &request, api.logger)
+		if err != nil {
+			return
+		}
+
+		name := strings.TrimSpace(request.Name)
+		err = util.ValidateStringSize(name, variables.MinNameSize, variables.MaxNameSize, variables.NameError, api.logger)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.NameError, err, api.logger)
+			return
+		}
+
+		for _, scope := range request.Scopes {
+			if !slices.Contains(variables.ApiTokenScopes, scope) {
+				util.SendResponse(w, r, http.StatusBadRequest, nil, variables.TokenScopeError, nil, api.logger)
+				return
+			}
+		}
+
+		token, info, err := api.core.IssueToken(userId, name, request.Scopes)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.IssueTokenError, err, api.logger)
+			return
+		}
+
+		response := communication.TokenCreatedResponse{Token: token, Info: info}
+		util.SendResponse(w, r, http.StatusCreated, response, variables.StatusOkMessage, nil, api.logger)
+	}
+}
+
+// @Summary Revoke API token
+// @Tags tokens
+// @Description Revoke an API token of the user. Requests with it are rejected afterwards
+// @ID revoke-api-token
+// @Produce json
+// @Param id path integer true "token id"
+// @Success 200 {string} string variables.StatusOkMessage
+// @Failure 400 {string} string variables.TokenIdError
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 404 {string} string variables.TokenNotFoundError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /tokens/{id} [delete]
+func (api *API) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userId, isAuth := r.Context().Value(variables.UserIDKey).(int64)
+	if !isAuth {
+		util.SendResponse(w, r, http.StatusUnauthorized, nil, variables.StatusUnauthorizedError, nil, api.logger)
+		return
+	}
+
+	tokenId, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/tokens/"), 10, 64)
+	if err != nil || tokenId < 1 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.TokenIdError, err, api.logger)
+		return
+	}
+
+	err = api.core.RevokeToken(userId, tokenId)
+	if errors.Is(err, variables.ErrTokenNotFound) {
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.TokenNotFoundError, err, api.logger)
+		return
+	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+		return
+	}
+
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+// @Summary Access token
+// @Tags tokens
+// @Description Issue a short-lived signed access token for the session or API token. Services verify it without calling the authorization service
+// @ID issue-access-token
+// @Produce json
+// @Success 200 {object} communication.AccessTokenResponse
+// @Failure 401 {string} string variables.StatusUnauthorizedError
+// @Failure 403 {string} string variables.AccessTokenRefreshError
+// @Failure 404 {string} string variables.AccessTokenDisabledError
+// @Failure 500 {string} string variables.StatusInternalServerError
+// @Router /access_token [post]
+func (api *API) AccessToken(w http.ResponseWriter, r *http.Request) {
+	userId, isAuth := r.Context().Value(variables.UserIDKey).(int64)
+	if !isAuth {
+		util.SendResponse(w, r, http.StatusUnauthorized, nil, variables.StatusUnauthorizedError, nil, api.logger)
+		return
+	}
+
+	// An access token must not extend itself past the session it was issued for.
+	credential, _ := middleware.Credentials(r)
+	if jwt.IsToken(credential) {
+		util.SendResponse(w, r, http.StatusForbidden, nil, variables.AccessTokenRefreshError, nil, api.logger)
+		return
+	}
+
+	scopes, _ := r.Context().Value(variables.ScopesKey).([]string)
+	token, expiresAt, err := api.core.IssueAccessToken(r.Context(), userId, scopes, util.HashToken(credential))
+	if errors.Is(err, variables.ErrJWTDisabled) {
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.AccessTokenDisabledError, err, api.logger)
+		return
+	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.IssueAccessTokenError, err, api.logger)
+		return
+	}
+
+	response := communication.AccessTokenResponse{AccessToken: token, TokenType: variables.AccessTokenResponseType, ExpiresAt: expiresAt}
+	util.SendResponse(w, r, http.StatusOK, response, variables.StatusOkMessage, nil, api.logger)
+}
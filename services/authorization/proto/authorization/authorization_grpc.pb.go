@@ -19,8 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Authorization_GetId_FullMethodName   = "/authorization.Authorization/GetId"
-	Authorization_GetRole_FullMethodName = "/authorization.Authorization/GetRole"
+	Authorization_GetId_FullMethodName            = "/authorization.Authorization/GetId"
+	Authorization_GetRole_FullMethodName          = "/authorization.Authorization/GetRole"
+	Authorization_GetKeys_FullMethodName          = "/authorization.Authorization/GetKeys"
+	Authorization_Authenticate_FullMethodName     = "/authorization.Authorization/Authenticate"
+	Authorization_WatchRevocations_FullMethodName = "/authorization.Authorization/WatchRevocations"
 )
 
 // AuthorizationClient is the client API for Authorization service.
@@ -29,6 +32,13 @@ const (
 type AuthorizationClient interface {
 	GetId(ctx context.Context, in *FindIdRequest, opts ...grpc.CallOption) (*FindIdResponse, error)
 	GetRole(ctx context.Context, in *RoleRequest, opts ...grpc.CallOption) (*RoleResponse, error)
+	GetKeys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (*KeysResponse, error)
+	// Authenticate resolves a session id or an API token in one call. Unknown
+	// credentials fail with UNAUTHENTICATED.
+	Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error)
+	// WatchRevocations streams credentials as they end, so services caching
+	// Authenticate results can drop them.
+	WatchRevocations(ctx context.Context, in *RevocationsRequest, opts ...grpc.CallOption) (Authorization_WatchRevocationsClient, error)
 }
 
 type authorizationClient struct {
@@ -57,12 +67,69 @@ func (c *authorizationClient) GetRole(ctx context.Context, in *RoleRequest, opts
 	return out, nil
 }
 
+func (c *authorizationClient) GetKeys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (*KeysResponse, error) {
+	out := new(KeysResponse)
+	err := c.cc.Invoke(ctx, Authorization_GetKeys_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorizationClient) Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error) {
+	out := new(AuthenticateResponse)
+	err := c.cc.Invoke(ctx, Authorization_Authenticate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authorizationClient) WatchRevocations(ctx context.Context, in *RevocationsRequest, opts ...grpc.CallOption) (Authorization_WatchRevocationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Authorization_ServiceDesc.Streams[0], Authorization_WatchRevocations_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &authorizationWatchRevocationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Authorization_WatchRevocationsClient interface {
+	Recv() (*Revocation, error)
+	grpc.ClientStream
+}
+
+type authorizationWatchRevocationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *authorizationWatchRevocationsClient) Recv() (*Revocation, error) {
+	m := new(Revocation)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // AuthorizationServer is the server API for Authorization service.
 // All implementations must embed UnimplementedAuthorizationServer
 // for forward compatibility
 type AuthorizationServer interface {
 	GetId(context.Context, *FindIdRequest) (*FindIdResponse, error)
 	GetRole(context.Context, *RoleRequest) (*RoleResponse, error)
+	GetKeys(context.Context, *KeysRequest) (*KeysResponse, error)
+	// Authenticate resolves a session id or an API token in one call. Unknown
+	// credentials fail with UNAUTHENTICATED.
+	Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error)
+	// WatchRevocations streams credentials as they end, so services caching
+	// Authenticate results can drop them.
+	WatchRevocations(*RevocationsRequest, Authorization_WatchRevocationsServer) error
 	mustEmbedUnimplementedAuthorizationServer()
 }
 
@@ -76,6 +143,15 @@ func (UnimplementedAuthorizationServer) GetId(context.Context, *FindIdRequest) (
 func (UnimplementedAuthorizationServer) GetRole(context.Context, *RoleRequest) (*RoleResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRole not implemented")
 }
+func (UnimplementedAuthorizationServer) GetKeys(context.Context, *KeysRequest) (*KeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetKeys not implemented")
+}
+func (UnimplementedAuthorizationServer) Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authenticate not implemented")
+}
+func (UnimplementedAuthorizationServer) WatchRevocations(*RevocationsRequest, Authorization_WatchRevocationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRevocations not implemented")
+}
 func (UnimplementedAuthorizationServer) mustEmbedUnimplementedAuthorizationServer() {}
 
 // UnsafeAuthorizationServer may be embedded to opt out of forward compatibility for this service.
@@ -125,6 +201,63 @@ func _Authorization_GetRole_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Authorization_GetKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorizationServer).GetKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Authorization_GetKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorizationServer).GetKeys(ctx, req.(*KeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authorization_Authenticate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthenticateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthorizationServer).Authenticate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Authorization_Authenticate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthorizationServer).Authenticate(ctx, req.(*AuthenticateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Authorization_WatchRevocations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RevocationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AuthorizationServer).WatchRevocations(m, &authorizationWatchRevocationsServer{stream})
+}
+
+type Authorization_WatchRevocationsServer interface {
+	Send(*Revocation) error
+	grpc.ServerStream
+}
+
+type authorizationWatchRevocationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *authorizationWatchRevocationsServer) Send(m *Revocation) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Authorization_ServiceDesc is the grpc.ServiceDesc for Authorization service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -140,7 +273,21 @@ var Authorization_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetRole",
 			Handler:    _Authorization_GetRole_Handler,
 		},
+		{
+			MethodName: "GetKeys",
+			Handler:    _Authorization_GetKeys_Handler,
+		},
+		{
+			MethodName: "Authenticate",
+			Handler:    _Authorization_Authenticate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRevocations",
+			Handler:       _Authorization_WatchRevocations_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "authorization.proto",
 }
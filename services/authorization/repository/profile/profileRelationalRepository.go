@@ -10,6 +10,7 @@ import (
 	"time"
 
 	_ "github.com/jackc/pgx/stdlib"
+	"github.com/lib/pq"
 )
 
 type ProfileRelationalRepository struct {
@@ -152,3 +153,35 @@ func (repository *ProfileRelationalRepository) GetUserRole(id int64) (string, er
 
 	return role, nil
 }
+
+// GetUserIdentity returns the profile with the given login and all of its
+// roles.
+func (repository *ProfileRelationalRepository) GetUserIdentity(login string) (models.Identity, error) {
+	return repository.getIdentity("profile.login = $1", login)
+}
+
+// GetUserIdentityById returns the profile with the given id and all of its
+// roles.
+func (repository *ProfileRelationalRepository) GetUserIdentityById(id int64) (models.Identity, error) {
+	return repository.getIdentity("profile.id = $1", id)
+}
+
+func (repository *ProfileRelationalRepository) getIdentity(condition string, value any) (models.Identity, error) {
+	var identity models.Identity
+
+	err := repository.db.QueryRow(`SELECT profile.id, profile.login,
+			COALESCE(array_agg(role.value ORDER BY role.id) FILTER (WHERE role.value IS NOT NULL), '{}')
+		FROM profile
+		LEFT JOIN profile_role ON profile.id = profile_role.profile_id
+		LEFT JOIN role ON profile_role.role_id = role.id
+		WHERE `+condition+`
+		GROUP BY profile.id`, value).Scan(&identity.UserID, &identity.Login, pq.Array(&identity.Roles))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Identity{}, fmt.Errorf(variables.ProfileIdNotFoundByLoginError+" %v", value)
+	}
+	if err != nil {
+		return models.Identity{}, fmt.Errorf(variables.FindProfileIdentityError+" %w", err)
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,90 @@
+package profile
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+func (repository *ProfileRelationalRepository) CreateToken(profileID int64, name string, hash []byte, scopes []string) (models.ApiToken, error) {
+	token := models.ApiToken{Name: name, Scopes: scopes}
+
+	err := repository.db.QueryRow(
+		`INSERT INTO api_token(profile_id, name, token_hash, scopes)
+			   VALUES ($1, $2, $3, $4)
+			   RETURNING id, created_at`,
+		profileID, name, hash, pq.Array(scopes)).Scan(&token.TokenID, &token.CreatedAt)
+	if err != nil {
+		return models.ApiToken{}, fmt.Errorf(variables.SqlTokenCreateError+" %w", err)
+	}
+
+	return token, nil
+}
+
+func (repository *ProfileRelationalRepository) GetTokens(profileID int64) ([]models.ApiToken, error) {
+	rows, err := repository.db.Query(
+		`SELECT id, name, scopes, created_at, revoked_at FROM api_token
+			   WHERE profile_id = $1
+			   ORDER BY id`, profileID)
+	if err != nil {
+		return nil, fmt.Errorf(variables.SqlTokenListError+" %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]models.ApiToken, 0)
+	for rows.Next() {
+		var token models.ApiToken
+		err := rows.Scan(&token.TokenID, &token.Name, pq.Array(&token.Scopes), &token.CreatedAt, &token.RevokedAt)
+		if err != nil {
+			return nil, fmt.Errorf(variables.SqlTokenListError+" %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(variables.SqlTokenListError+" %w", err)
+	}
+
+	return tokens, nil
+}
+
+// RevokeToken marks the token of the profile as revoked and returns its hash.
+// Revoking a token twice keeps the first revocation time.
+func (repository *ProfileRelationalRepository) RevokeToken(profileID int64, tokenID int64) ([]byte, error) {
+	var hash []byte
+
+	err := repository.db.QueryRow(
+		`UPDATE api_token SET revoked_at = COALESCE(revoked_at, NOW())
+			   WHERE id = $1 AND profile_id = $2
+			   RETURNING token_hash`, tokenID, profileID).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, variables.ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf(variables.SqlTokenRevokeError+" %w", err)
+	}
+
+	return hash, nil
+}
+
+// GetTokenOwner returns the profile and scopes of a token that is not revoked.
+func (repository *ProfileRelationalRepository) GetTokenOwner(hash []byte) (int64, []string, error) {
+	var profileID int64
+	var scopes []string
+
+	err := repository.db.QueryRow(
+		`SELECT profile_id, scopes FROM api_token
+			   WHERE token_hash = $1 AND revoked_at IS NULL`, hash).Scan(&profileID, pq.Array(&scopes))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil, variables.ErrTokenNotFound
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf(variables.FindTokenOwnerError+" %w", err)
+	}
+
+	return profileID, scopes, nil
+}
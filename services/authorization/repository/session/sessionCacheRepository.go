@@ -2,8 +2,10 @@ package session
 
 import (
 	"avito-track/pkg/models"
+	"avito-track/pkg/util"
 	"avito-track/pkg/variables"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"time"
@@ -11,6 +13,12 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+// sessionLogID identifies a session in the logs without revealing its id,
+// which is a credential.
+func sessionLogID(sid string) string {
+	return hex.EncodeToString(util.HashToken(sid)[:variables.SessionLogIDLength])
+}
+
 type SessionCacheRepository struct {
 	sessionRedisClient *redis.Client
 }
@@ -104,7 +112,7 @@ func (sessionCacheRepository *SessionCacheRepository) SaveSessionCache(ctx conte
 func (sessionCacheRepository *SessionCacheRepository) GetSessionCache(ctx context.Context, sid string, logger *slog.Logger) (bool, error) {
 	_, err := sessionCacheRepository.sessionRedisClient.Get(ctx, sid).Result()
 	if err == redis.Nil {
-		logger.Error(variables.SessionNotFoundError, sid)
+		logger.Error(variables.SessionNotFoundError, "sid", sessionLogID(sid))
 		return false, nil
 	}
 
@@ -129,9 +137,33 @@ func (sessionCacheRepository *SessionCacheRepository) DeleteSessionCache(ctx con
 func (sessionCacheRepository *SessionCacheRepository) GetUserLogin(ctx context.Context, sid string, logger *slog.Logger) (string, error) {
 	value, err := sessionCacheRepository.sessionRedisClient.Get(ctx, sid).Result()
 	if err != nil {
-		logger.Error(variables.SessionNotFoundError + sid)
+		logger.Error(variables.SessionNotFoundError, "sid", sessionLogID(sid))
 		return "", err
 	}
 
 	return value, nil
 }
+
+// GetSession returns the login and expiry of the session. An unknown session
+// is reported as variables.ErrSessionNotFound.
+func (sessionCacheRepository *SessionCacheRepository) GetSession(ctx context.Context, sid string, logger *slog.Logger) (models.Session, error) {
+	pipe := sessionCacheRepository.sessionRedisClient.Pipeline()
+	login := pipe.Get(ctx, sid)
+	ttl := pipe.TTL(ctx, sid)
+
+	_, err := pipe.Exec(ctx)
+	if err == redis.Nil {
+		logger.Error(variables.SessionNotFoundError, "sid", sessionLogID(sid))
+		return models.Session{}, variables.ErrSessionNotFound
+	}
+	if err != nil {
+		logger.Error(variables.StatusInternalServerError, "error", err.Error())
+		return models.Session{}, err
+	}
+
+	return models.Session{
+		Login:     login.Val(),
+		SID:       sid,
+		ExpiresAt: time.Now().Add(ttl.Val()),
+	}, nil
+}
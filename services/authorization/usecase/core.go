@@ -1,15 +1,18 @@
 package usecase
 
 import (
+	"avito-track/pkg/jwt"
 	"avito-track/pkg/models"
 	"avito-track/pkg/util"
 	"avito-track/pkg/variables"
 	"avito-track/services/authorization/repository/profile"
 	"avito-track/services/authorization/repository/session"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"regexp"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,6 +23,12 @@ type IProfileRelationalRepository interface {
 	GetUser(login string, password []byte) (*models.UserItem, bool, error)
 	GetUserProfileId(login string) (int64, error)
 	GetUserRole(id int64) (string, error)
+	CreateToken(profileID int64, name string, hash []byte, scopes []string) (models.ApiToken, error)
+	GetTokens(profileID int64) ([]models.ApiToken, error)
+	RevokeToken(profileID int64, tokenID int64) ([]byte, error)
+	GetTokenOwner(hash []byte) (int64, []string, error)
+	GetUserIdentity(login string) (models.Identity, error)
+	GetUserIdentityById(id int64) (models.Identity, error)
 }
 
 type ISessionCacheRepository interface {
@@ -27,16 +36,19 @@ type ISessionCacheRepository interface {
 	GetSessionCache(ctx context.Context, sid string, logger *slog.Logger) (bool, error)
 	DeleteSessionCache(ctx context.Context, sid string, logger *slog.Logger) (bool, error)
 	GetUserLogin(ctx context.Context, sid string, logger *slog.Logger) (string, error)
+	GetSession(ctx context.Context, sid string, logger *slog.Logger) (models.Session, error)
 }
 
 type Core struct {
-	sessions ISessionCacheRepository
-	logger   *slog.Logger
-	mutex    sync.RWMutex
-	profiles IProfileRelationalRepository
+	sessions    ISessionCacheRepository
+	logger      *slog.Logger
+	mutex       sync.RWMutex
+	profiles    IProfileRelationalRepository
+	keys        *SigningKeys
+	revocations *Revocations
 }
 
-func GetCore(profileConfig *variables.RelationalDataBaseConfig, sessionConfig *variables.CacheDataBaseConfig, logger *slog.Logger) (*Core, error) {
+func GetCore(profileConfig *variables.RelationalDataBaseConfig, sessionConfig *variables.CacheDataBaseConfig, keys *SigningKeys, revocations *Revocations, logger *slog.Logger) (*Core, error) {
 	sessionRepository, err := session.GetSessionRepository(sessionConfig, logger)
 	if err != nil {
 		logger.Error(variables.SessionRepositoryNotActiveError)
@@ -50,9 +62,11 @@ func GetCore(profileConfig *variables.RelationalDataBaseConfig, sessionConfig *v
 	}
 
 	core := Core{
-		sessions: sessionRepository,
-		logger:   logger.With(variables.ModuleLogger, variables.CoreModuleLogger),
-		profiles: profileRepository,
+		sessions:    sessionRepository,
+		logger:      logger.With(variables.ModuleLogger, variables.CoreModuleLogger),
+		profiles:    profileRepository,
+		keys:        keys,
+		revocations: revocations,
 	}
 
 	return &core, nil
@@ -90,6 +104,7 @@ func (core *Core) KillSession(ctx context.Context, sid string) error {
 		return err
 	}
 
+	core.revocations.publish(util.HashToken(sid))
 	return nil
 }
 
@@ -146,18 +161,41 @@ func (core *Core) FindUserAccount(login string, password string) (*models.UserIt
 	return user, found, nil
 }
 
-func (core *Core) GetUserId(ctx context.Context, sid string) (int64, error) {
-	login, err := core.sessions.GetUserLogin(ctx, sid, core.logger)
+func (core *Core) Authenticate(ctx context.Context, sid string) (models.Identity, error) {
+	return Authenticate(ctx, sid, core.sessions, core.profiles, core.logger)
+}
+
+// Authenticate resolves a session id or an API token to the user it belongs
+// to. Unknown or revoked credentials are reported as
+// variables.ErrSessionNotFound or variables.ErrTokenNotFound.
+func Authenticate(ctx context.Context, sid string, sessions ISessionCacheRepository, profiles IProfileRelationalRepository, logger *slog.Logger) (models.Identity, error) {
+	if strings.HasPrefix(sid, variables.ApiTokenPrefix) {
+		id, scopes, err := profiles.GetTokenOwner(util.HashToken(sid))
+		if err != nil {
+			return models.Identity{}, err
+		}
+
+		identity, err := profiles.GetUserIdentityById(id)
+		if err != nil {
+			logger.Error(variables.GetProfileError, "error", err.Error())
+			return models.Identity{}, err
+		}
+		identity.Scopes = scopes
+		return identity, nil
+	}
+
+	session, err := sessions.GetSession(ctx, sid, logger)
 	if err != nil {
-		return 0, err
+		return models.Identity{}, err
 	}
 
-	id, err := core.profiles.GetUserProfileId(login)
+	identity, err := profiles.GetUserIdentity(session.Login)
 	if err != nil {
-		core.logger.Error(variables.GetProfileError, " id: %v", err)
-		return 0, err
+		logger.Error(variables.GetProfileError, "error", err.Error())
+		return models.Identity{}, err
 	}
-	return id, nil
+	identity.ExpiresAt = &session.ExpiresAt
+	return identity, nil
 }
 
 func (core *Core) GetUserRole(ctx context.Context, id int64) (string, error) {
@@ -169,3 +207,87 @@ func (core *Core) GetUserRole(ctx context.Context, id int64) (string, error) {
 
 	return role, nil
 }
+
+// IssueToken creates an API token for the profile and returns it together
+// with its description. The token cannot be recovered later.
+func (core *Core) IssueToken(profileID int64, name string, scopes []string) (string, models.ApiToken, error) {
+	token, err := util.NewApiToken()
+	if err != nil {
+		core.logger.Error(variables.IssueTokenError, ": %w", err)
+		return "", models.ApiToken{}, err
+	}
+
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	info, err := core.profiles.CreateToken(profileID, name, util.HashToken(token), scopes)
+	if err != nil {
+		core.logger.Error(variables.IssueTokenError, ": %w", err)
+		return "", models.ApiToken{}, err
+	}
+
+	return token, info, nil
+}
+
+func (core *Core) ListTokens(profileID int64) ([]models.ApiToken, error) {
+	tokens, err := core.profiles.GetTokens(profileID)
+	if err != nil {
+		core.logger.Error(variables.TokenRequestError, ": %w", err)
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (core *Core) RevokeToken(profileID int64, tokenID int64) error {
+	hash, err := core.profiles.RevokeToken(profileID, tokenID)
+	if err != nil {
+		if !errors.Is(err, variables.ErrTokenNotFound) {
+			core.logger.Error(variables.TokenRequestError, ": %w", err)
+		}
+		return err
+	}
+
+	core.revocations.publish(hash)
+	return nil
+}
+
+// IssueAccessToken signs a short-lived access token carrying the user id, role
+// and scopes, so services can authorize it without calling this service. The
+// token is revoked together with the credential it is issued for.
+func (core *Core) IssueAccessToken(ctx context.Context, userID int64, scopes []string, credentialHash []byte) (string, time.Time, error) {
+	if core.keys == nil {
+		return "", time.Time{}, variables.ErrJWTDisabled
+	}
+
+	role, err := core.GetUserRole(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	claims := jwt.Claims{CredentialID: jwt.CredentialID(credentialHash), UserID: userID, Role: role, Scopes: scopes}
+	token, expiresAt, err := core.keys.sign(claims)
+	if err != nil {
+		core.logger.Error(variables.IssueAccessTokenError, ": %w", err)
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+func (core *Core) VerifyAccessToken(token string) (jwt.Claims, error) {
+	if core.keys == nil {
+		return jwt.Claims{}, variables.ErrJWTDisabled
+	}
+
+	claims, err := jwt.Parse(token, core.keys.publicKey)
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+
+	if core.revocations.revoked.Contains(claims.CredentialID) {
+		return jwt.Claims{}, variables.ErrRevokedJWT
+	}
+	return claims, nil
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"avito-track/pkg/jwt"
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type signingKey struct {
+	id        string
+	private   ed25519.PrivateKey
+	public    ed25519.PublicKey
+	retiredAt time.Time
+}
+
+// SigningKeys holds the key access tokens are signed with and the retired keys
+// whose tokens may still be valid. Keys only live in memory, so tokens do not
+// survive a restart of the service.
+type SigningKeys struct {
+	mutex sync.RWMutex
+	ttl   time.Duration
+	keys  []signingKey
+}
+
+// NewSigningKeys returns nil when access tokens are disabled.
+func NewSigningKeys(config *variables.AccessTokenConfig, logger *slog.Logger) (*SigningKeys, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	keys := &SigningKeys{ttl: time.Duration(config.TTLMinutes) * time.Minute}
+	err := keys.rotate()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.RotationHours > 0 {
+		go keys.runRotation(time.Duration(config.RotationHours)*time.Hour, logger)
+	}
+
+	return keys, nil
+}
+
+// rotate starts signing with a new key. The previous key is published until
+// the last token signed with it expires.
+func (keys *SigningKeys) rotate() error {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(public)
+	key := signingKey{
+		id:      base64.RawURLEncoding.EncodeToString(hash[:8]),
+		private: private,
+		public:  public,
+	}
+
+	now := time.Now()
+
+	keys.mutex.Lock()
+	defer keys.mutex.Unlock()
+
+	active := make([]signingKey, 0, len(keys.keys)+1)
+	for _, old := range keys.keys {
+		if old.retiredAt.IsZero() {
+			old.retiredAt = now
+		}
+		if now.Before(old.retiredAt.Add(keys.ttl)) {
+			active = append(active, old)
+		}
+	}
+	keys.keys = append(active, key)
+
+	return nil
+}
+
+func (keys *SigningKeys) runRotation(interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := keys.rotate()
+		if err != nil {
+			logger.Error(variables.SigningKeysError, "error", err.Error())
+		}
+	}
+}
+
+func (keys *SigningKeys) sign(claims jwt.Claims) (string, time.Time, error) {
+	keys.mutex.RLock()
+	current := keys.keys[len(keys.keys)-1]
+	keys.mutex.RUnlock()
+
+	now := time.Now()
+	expiresAt := now.Add(keys.ttl)
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = expiresAt.Unix()
+
+	token, err := jwt.Sign(claims, current.id, current.private)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+func (keys *SigningKeys) publicKey(keyID string) (ed25519.PublicKey, error) {
+	keys.mutex.RLock()
+	defer keys.mutex.RUnlock()
+
+	for _, key := range keys.keys {
+		if key.id == keyID {
+			return key.public, nil
+		}
+	}
+
+	return nil, variables.ErrUnknownJWTKey
+}
+
+// Verification returns the public keys services verify access tokens with.
+func (keys *SigningKeys) Verification() []models.VerificationKey {
+	if keys == nil {
+		return nil
+	}
+
+	keys.mutex.RLock()
+	defer keys.mutex.RUnlock()
+
+	verification := make([]models.VerificationKey, 0, len(keys.keys))
+	for _, key := range keys.keys {
+		var expiresAt time.Time
+		if !key.retiredAt.IsZero() {
+			expiresAt = key.retiredAt.Add(keys.ttl)
+		}
+		verification = append(verification, models.VerificationKey{KeyID: key.id, PublicKey: key.public, ExpiresAt: expiresAt})
+	}
+
+	return verification
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"avito-track/pkg/jwt"
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Revocations fans ended credentials out to the services watching them. A
+// subscriber that falls behind loses events and relies on its cache TTL.
+// Ended credentials are also remembered for the access token TTL, so access
+// tokens issued for them are rejected here as well.
+type Revocations struct {
+	mutex          sync.Mutex
+	subscribers    map[chan models.Revocation]struct{}
+	accessTokenTTL time.Duration
+	revoked        *jwt.RevocationList
+	logger         *slog.Logger
+}
+
+// NewRevocations takes the lifetime of access tokens, zero when they are
+// disabled.
+func NewRevocations(accessTokenTTL time.Duration, logger *slog.Logger) *Revocations {
+	return &Revocations{
+		subscribers:    make(map[chan models.Revocation]struct{}),
+		accessTokenTTL: accessTokenTTL,
+		revoked:        jwt.NewRevocationList(),
+		logger:         logger,
+	}
+}
+
+// Subscribe returns a channel of ended credentials and the function that
+// stops the subscription.
+func (revocations *Revocations) Subscribe() (<-chan models.Revocation, func()) {
+	events := make(chan models.Revocation, variables.RevocationsBufferSize)
+
+	revocations.mutex.Lock()
+	revocations.subscribers[events] = struct{}{}
+	revocations.mutex.Unlock()
+
+	return events, func() {
+		revocations.mutex.Lock()
+		delete(revocations.subscribers, events)
+		revocations.mutex.Unlock()
+	}
+}
+
+func (revocations *Revocations) publish(credentialHash []byte) {
+	revocation := models.Revocation{CredentialHash: credentialHash}
+	if revocations.accessTokenTTL > 0 {
+		revocation.AccessTokensUntil = time.Now().Add(revocations.accessTokenTTL)
+		revocations.revoked.Add(jwt.CredentialID(credentialHash), revocation.AccessTokensUntil)
+	}
+
+	revocations.mutex.Lock()
+	defer revocations.mutex.Unlock()
+
+	for events := range revocations.subscribers {
+		select {
+		case events <- revocation:
+		default:
+			revocations.logger.Error(variables.RevocationDroppedMessage)
+		}
+	}
+}
@@ -1,28 +1,55 @@
 package delivery
 
 import (
+	"avito-track/pkg/jwt"
 	"avito-track/pkg/middleware"
 	"avito-track/pkg/models"
 	communication "avito-track/pkg/requests"
 	"avito-track/pkg/util"
 	"avito-track/pkg/variables"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ICore interface {
-	UserBanner(tagID int64, featureID int64, useLastRevision bool) (*models.Banner, error)
-	GetBanners(userRole string, featureID int64, tagIDs []int64, limit, offset int64) ([]models.Banner, error)
-	AddBanner(tagIDs []int64, featureID int64, content string) error
-	UpdateBanner(id int64, tagIds []int64, featureID int64, content string) error
+	UserBanner(tagID int64, featureID int64, userID int64, useLastRevision bool) (*models.Banner, error)
+	GetBanners(userRole string, filter models.BannerFilter, sort models.BannerSort, limit, offset int64) ([]models.Banner, error)
+	GetBannersPage(userRole string, filter models.BannerFilter, after *models.BannerCursor, limit int64, withTotal bool) (models.BannerPage, error)
+	AddBanner(banner models.Banner) (int64, error)
+	UpdateBanner(id int64, patch models.BannerPatch) error
 	DeleteBanner(id int64) error
-	GetUserId(ctx context.Context, sid string) (int64, error)
+	BannerVersions(id int64, limit, offset int64) ([]models.BannerVersion, error)
+	RollbackBanner(id int64, versionID int64) error
+	DeleteBanners(featureID int64, tagID int64) (models.DeleteJob, error)
+	DeleteJob(id int64) (models.DeleteJob, error)
+	FeatureSchema(featureID int64) (json.RawMessage, error)
+	SetFeatureSchema(featureID int64, schema json.RawMessage) error
+	GetFeatures(limit, offset int64) ([]models.Feature, error)
+	AddFeature(name string) (int64, error)
+	RenameFeature(id int64, name string) error
+	DeleteFeature(id int64) error
+	GetTags(limit, offset int64) ([]models.Tag, error)
+	AddTag(name string) (int64, error)
+	RenameTag(id int64, name string) error
+	DeleteTag(id int64) error
+	RecordImpression(bannerID int64)
+	RecordClick(tagID int64, featureID int64, userID int64, bannerID int64) error
+	BannerStats(id int64, from, to string) ([]models.BannerStats, error)
+	ExportBanners(write func(models.Banner) error) error
+	ImportBanners(banners []models.Banner, dryRun bool) (models.ImportResult, error)
+	Authenticate(ctx context.Context, sid string) (models.Identity, error)
+	AuthCacheStats() models.AuthCacheStats
 	GetUserRole(ctx context.Context, id int64) (string, error)
+	VerifyAccessToken(token string) (jwt.Claims, error)
 }
 
 type API struct {
@@ -54,6 +81,13 @@ func GetApi(bannerCore ICore, bannerLogger *slog.Logger) *API {
 			api.logger),
 		variables.MethodGet, api.logger))
 
+	api.mux.Handle("/api/v1/user_banner/click", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			http.HandlerFunc(api.UserBannerClick),
+			api.core,
+			api.logger),
+		variables.MethodPost, api.logger))
+
 	api.mux.Handle("/api/v1/banner", middleware.MethodMiddleware(
 		middleware.AuthorizationMiddleware(
 			middleware.PermissionsMiddleware(
@@ -63,7 +97,7 @@ func GetApi(bannerCore ICore, bannerLogger *slog.Logger) *API {
 				api.logger),
 			api.core,
 			api.logger),
-		variables.MethodGetAndPost, api.logger))
+		variables.MethodsGetPostDelete, api.logger))
 
 	api.mux.Handle("/api/v1/banner/", middleware.MethodMiddleware(
 		middleware.AuthorizationMiddleware(
@@ -74,7 +108,95 @@ func GetApi(bannerCore ICore, bannerLogger *slog.Logger) *API {
 				api.logger),
 			api.core,
 			api.logger),
+		variables.MethodsGetPostDeletePatch, api.logger))
+
+	api.mux.Handle("/api/v1/banner/export", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.ExportBanners),
+				api.core,
+				variables.AdminRole,
+				api.logger),
+			api.core,
+			api.logger),
+		variables.MethodGet, api.logger))
+
+	api.mux.Handle("/api/v1/banner/import", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.ImportBanners),
+				api.core,
+				variables.AdminRole,
+				api.logger),
+			api.core,
+			api.logger),
+		variables.MethodPost, api.logger))
+
+	api.mux.Handle("/api/v1/banner/jobs/", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.DeleteJob),
+				api.core,
+				variables.AdminRole,
+				api.logger),
+			api.core,
+			api.logger),
+		variables.MethodGet, api.logger))
+
+	api.mux.Handle("/api/v1/feature", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.Features),
+				api.core,
+				variables.AdminRole,
+				api.logger),
+			api.core,
+			api.logger),
+		variables.MethodGetAndPost, api.logger))
+
+	api.mux.Handle("/api/v1/feature/", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.FeatureSettings),
+				api.core,
+				variables.AdminRole,
+				api.logger),
+			api.core,
+			api.logger),
+		variables.MethodsGetPutDeletePatch, api.logger))
+
+	api.mux.Handle("/api/v1/tag", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.Tags),
+				api.core,
+				variables.AdminRole,
+				api.logger),
+			api.core,
+			api.logger),
+		variables.MethodGetAndPost, api.logger))
+
+	api.mux.Handle("/api/v1/tag/", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.TagSettings),
+				api.core,
+				variables.AdminRole,
+				api.logger),
+			api.core,
+			api.logger),
 		variables.MethodsDeletePatch, api.logger))
+
+	api.mux.Handle("/api/v1/metrics/auth_cache", middleware.MethodMiddleware(
+		middleware.AuthorizationMiddleware(
+			middleware.PermissionsMiddleware(
+				http.HandlerFunc(api.AuthCacheStats),
+				api.core,
+				variables.AdminRole,
+				api.logger),
+			api.core,
+			api.logger),
+		variables.MethodGet, api.logger))
 	return api
 }
 
@@ -105,13 +227,74 @@ func (api *API) UserBanner(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	banner, err := api.core.UserBanner(tagID, featureID, useLastRevision)
+	userID, _ := r.Context().Value(variables.UserIDKey).(int64)
+
+	banner, err := api.core.UserBanner(tagID, featureID, userID, useLastRevision)
+	if err != nil || banner == nil {
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.BannerNotFoundError, err, api.logger)
+		return
+	}
+
+	maxAge := variables.BannerCacheTTL
+	if useLastRevision {
+		maxAge = 0
+	}
+	etag := bannerETag(banner)
+	headers := util.CacheHeaders(etag, maxAge)
+
+	if util.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		util.SendResponse(w, r, http.StatusNotModified, nil, variables.StatusOkMessage, nil, api.logger, headers)
+		return
+	}
+	api.core.RecordImpression(banner.BannerID)
+	util.SendResponse(w, r, http.StatusOK, banner, variables.StatusOkMessage, nil, api.logger, headers)
+}
+
+// bannerETag identifies what a user banner response carries: the banner, its
+// current version and the variant served.
+func bannerETag(banner *models.Banner) string {
+	etag := strconv.FormatInt(banner.BannerID, 10) + "-" + strconv.FormatInt(banner.VersionID, 10)
+	if banner.Variant != "" {
+		etag += "-" + banner.Variant
+	}
+	return strconv.Quote(etag)
+}
+
+func (api *API) UserBannerClick(w http.ResponseWriter, r *http.Request) {
+	var click communication.ClickRequest
+	err := util.GetRequestBody(w, r, &click, api.logger)
 	if err != nil {
+		return
+	}
+
+	if click.BannerId < 1 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.BannerIdError, nil, api.logger)
+		return
+	}
+
+	if click.TagId < 1 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.TagIdError, nil, api.logger)
+		return
+	}
+
+	if click.FeatureId < 1 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.FeatureIdError, nil, api.logger)
+		return
+	}
+
+	userID, _ := r.Context().Value(variables.UserIDKey).(int64)
+
+	err = api.core.RecordClick(click.TagId, click.FeatureId, userID, click.BannerId)
+	if errors.Is(err, variables.ErrBannerNotFound) {
 		util.SendResponse(w, r, http.StatusNotFound, nil, variables.BannerNotFoundError, err, api.logger)
 		return
 	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+		return
+	}
 
-	util.SendResponse(w, r, http.StatusOK, banner, variables.StatusOkMessage, nil, api.logger)
+	util.SendResponse(w, r, http.StatusAccepted, nil, variables.StatusOkMessage, nil, api.logger)
 }
 
 func (api *API) BannersList(w http.ResponseWriter, r *http.Request) {
@@ -148,33 +331,48 @@ func (api *API) BannersList(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		limitStr := r.URL.Query().Get("limit")
-		var limit int64
-		if limitStr != "" {
-			lim, err := strconv.ParseInt(limitStr, 10, 64)
-			if err != nil || lim < 1 {
-				util.SendResponse(w, r, http.StatusBadRequest, nil, variables.InvalidLimit, err, api.logger)
-				return
-			}
-			limit = lim
-		} else {
-			limit = 10
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", variables.BannerStatusScheduled, variables.BannerStatusLive, variables.BannerStatusExpired:
+		default:
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.BannerStatusError, nil, api.logger)
+			return
 		}
 
-		offsetStr := r.URL.Query().Get("offset")
-		var offset int64
-		if offsetStr != "" {
-			off, err := strconv.ParseInt(offsetStr, 10, 64)
-			if err != nil || off < 0 {
-				util.SendResponse(w, r, http.StatusBadRequest, nil, variables.InvalidOffset, err, api.logger)
+		filter := models.BannerFilter{
+			FeatureID: featureID,
+			TagIDs:    tagIDs,
+			Status:    status,
+			Query:     strings.TrimSpace(r.URL.Query().Get("q")),
+		}
+
+		limit, offset, err := getLimitOffset(w, r, api.logger)
+		if err != nil {
+			return
+		}
+
+		sortField := r.URL.Query().Get("sort")
+		order := r.URL.Query().Get("order")
+		if r.URL.Query().Has("cursor") {
+			if sortField != "" || order != "" {
+				util.SendResponse(w, r, http.StatusBadRequest, nil, variables.SortCursorError, nil, api.logger)
 				return
 			}
-			offset = off
-		} else {
-			offset = 0
+			api.bannersPage(w, r, userRole, filter, limit)
+			return
 		}
 
-		banners, err := api.core.GetBanners(userRole, featureID, tagIDs, limit, offset)
+		sort, err := getSort(sortField, order)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.SortError, err, api.logger)
+			return
+		}
+
+		banners, err := api.core.GetBanners(userRole, filter, sort, limit, offset)
+		if errors.Is(err, variables.ErrInvalidQuery) {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.SearchQueryError, err, api.logger)
+			return
+		}
 		if err != nil {
 			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
 			return
@@ -200,44 +398,232 @@ func (api *API) BannersList(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = api.core.AddBanner(banner.TagIds, banner.FeatureId, banner.Content)
+		newBanner, message := bannerFromRequest(banner)
+		if message != "" {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, message, nil, api.logger)
+			return
+		}
+
+		id, err := api.core.AddBanner(newBanner)
+		var conflict *variables.BannerConflict
+		if errors.As(err, &conflict) {
+			api.sendConflict(w, r, conflict)
+			return
+		}
+		var validation *variables.ContentValidation
+		if errors.As(err, &validation) {
+			api.sendContentValidation(w, r, validation)
+			return
+		}
+		var unknown *variables.UnknownReferences
+		if errors.As(err, &unknown) {
+			api.sendUnknownReferences(w, r, unknown)
+			return
+		}
+		if errors.Is(err, variables.ErrInvalidSchedule) {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.ScheduleError, err, api.logger)
+			return
+		}
 		if err != nil {
 			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, nil, api.logger)
 			return
 		}
 
-		util.SendResponse(w, r, http.StatusCreated, nil, variables.StatusOkMessage, nil, api.logger)
+		headers := http.Header{"Location": {"/api/v1/banner/" + strconv.FormatInt(id, 10)}}
+		util.SendResponse(w, r, http.StatusCreated, communication.BannerCreatedResponse{BannerID: id}, variables.StatusOkMessage, nil, api.logger, headers)
+	case http.MethodDelete:
+		if userRole != variables.AdminRole[0] {
+			util.SendResponse(w, r, http.StatusForbidden, nil, variables.StatusForbiddenError, nil, api.logger)
+			return
+		}
+
+		var featureID int64
+		featureIDStr := r.URL.Query().Get("feature_id")
+		if featureIDStr != "" {
+			var err error
+			featureID, err = strconv.ParseInt(featureIDStr, 10, 64)
+			if err != nil || featureID < 1 {
+				util.SendResponse(w, r, http.StatusBadRequest, nil, variables.FeatureIdError, err, api.logger)
+				return
+			}
+		}
+
+		var tagID int64
+		tagIDStr := r.URL.Query().Get("tag_id")
+		if tagIDStr != "" {
+			var err error
+			tagID, err = strconv.ParseInt(tagIDStr, 10, 64)
+			if err != nil || tagID < 1 {
+				util.SendResponse(w, r, http.StatusBadRequest, nil, variables.TagIdError, err, api.logger)
+				return
+			}
+		}
+
+		if featureID == 0 && tagID == 0 {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.DeleteFilterError, nil, api.logger)
+			return
+		}
+
+		job, err := api.core.DeleteBanners(featureID, tagID)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		headers := http.Header{"Location": {"/api/v1/banner/jobs/" + strconv.FormatInt(job.JobID, 10)}}
+		util.SendResponse(w, r, http.StatusAccepted, job, variables.StatusOkMessage, nil, api.logger, headers)
+	}
+}
+
+// bannersPage serves the cursor paginated banner list. An empty cursor starts
+// from the newest banner.
+func (api *API) bannersPage(w http.ResponseWriter, r *http.Request, userRole string, filter models.BannerFilter, limit int64) {
+	var after *models.BannerCursor
+	cursorStr := r.URL.Query().Get("cursor")
+	if cursorStr != "" {
+		cursor, err := decodeCursor(cursorStr)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.CursorError, err, api.logger)
+			return
+		}
+		after = cursor
+	}
+
+	var withTotal bool
+	withTotalStr := r.URL.Query().Get("with_total")
+	if withTotalStr != "" {
+		var err error
+		withTotal, err = strconv.ParseBool(withTotalStr)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.WithTotalError, err, api.logger)
+			return
+		}
+	}
+
+	page, err := api.core.GetBannersPage(userRole, filter, after, limit, withTotal)
+	if errors.Is(err, variables.ErrInvalidQuery) {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.SearchQueryError, err, api.logger)
+		return
+	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+		return
+	}
+
+	response := communication.BannerPageResponse{
+		Banners: page.Banners,
+		Total:   page.Total,
+	}
+	if page.Next != nil {
+		response.NextCursor = encodeCursor(*page.Next)
+	}
+
+	util.SendResponse(w, r, http.StatusOK, response, variables.StatusOkMessage, nil, api.logger)
+}
+
+func (api *API) DeleteJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Path[len("/api/v1/banner/jobs/"):], 10, 64)
+	if err != nil || id < 1 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.DeleteJobIdError, err, api.logger)
+		return
 	}
+
+	job, err := api.core.DeleteJob(id)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.DeleteJobNotFoundError, err, api.logger)
+		return
+	}
+
+	util.SendResponse(w, r, http.StatusOK, job, variables.StatusOkMessage, nil, api.logger)
+}
+
+func (api *API) AuthCacheStats(w http.ResponseWriter, r *http.Request) {
+	util.SendResponse(w, r, http.StatusOK, api.core.AuthCacheStats(), variables.StatusOkMessage, nil, api.logger)
 }
 
 func (api *API) BannersSettings(w http.ResponseWriter, r *http.Request) {
-	idStr := r.URL.Path[len("/api/v1/banner/"):]
+	idStr, subresource, _ := strings.Cut(r.URL.Path[len("/api/v1/banner/"):], "/")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil || id < 1 {
 		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.StatusBadRequestError, err, api.logger)
 		return
 	}
 
+	switch subresource {
+	case "":
+		api.BannerItem(w, r, id)
+	case variables.BannerVersionsPath:
+		api.BannerVersions(w, r, id)
+	case variables.BannerRollbackPath:
+		api.BannerRollback(w, r, id)
+	case variables.BannerStatsPath:
+		api.BannerStats(w, r, id)
+	default:
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.StatusNotFoundError, nil, api.logger)
+	}
+}
+
+func (api *API) BannerItem(w http.ResponseWriter, r *http.Request, id int64) {
 	switch r.Method {
 	case http.MethodPatch:
-		var banner communication.BannerRequest
-		body, err := io.ReadAll(r.Body)
+		var banner communication.BannerPatchRequest
+		err := util.GetRequestBody(w, r, &banner, api.logger)
 		if err != nil {
-			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.StatusBadRequestError, err, api.logger)
 			return
 		}
 
-		err = json.Unmarshal(body, &banner)
-		if err != nil {
-			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+		if banner.Content != nil && !util.IsJsonObject(*banner.Content) {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.ContentError, nil, api.logger)
 			return
 		}
 
-		err = api.core.UpdateBanner(id, banner.TagIds, banner.FeatureId, banner.Content)
-		if err != nil {
+		if banner.TagIds != nil && !uniqueTagIDs(*banner.TagIds) {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.DuplicateTagIdsError, nil, api.logger)
+			return
+		}
+
+		if banner.Variants != nil && !validVariants(*banner.Variants) {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.VariantsError, nil, api.logger)
+			return
+		}
+
+		patch := models.BannerPatch{
+			TagIDs:    banner.TagIds,
+			FeatureID: banner.FeatureId,
+			Content:   banner.Content,
+			Variants:  banner.Variants,
+			IsActive:  banner.IsActive,
+			StartsAt:  banner.StartsAt,
+			EndsAt:    banner.EndsAt,
+		}
+		err = api.core.UpdateBanner(id, patch)
+		var conflict *variables.BannerConflict
+		if errors.As(err, &conflict) {
+			api.sendConflict(w, r, conflict)
+			return
+		}
+		var validation *variables.ContentValidation
+		if errors.As(err, &validation) {
+			api.sendContentValidation(w, r, validation)
+			return
+		}
+		var unknown *variables.UnknownReferences
+		if errors.As(err, &unknown) {
+			api.sendUnknownReferences(w, r, unknown)
+			return
+		}
+		if errors.Is(err, variables.ErrBannerNotFound) {
 			util.SendResponse(w, r, http.StatusNotFound, nil, variables.BannerNotFoundError, err, api.logger)
 			return
 		}
+		if errors.Is(err, variables.ErrInvalidSchedule) {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.ScheduleError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
 
 		util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
 	case http.MethodDelete:
@@ -247,5 +633,281 @@ func (api *API) BannersSettings(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+	default:
+		util.SendResponse(w, r, http.StatusMethodNotAllowed, nil, variables.StatusMethodNotAllowedError, nil, api.logger)
+	}
+}
+
+func (api *API) BannerVersions(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		util.SendResponse(w, r, http.StatusMethodNotAllowed, nil, variables.StatusMethodNotAllowedError, nil, api.logger)
+		return
+	}
+
+	limit, offset, err := getLimitOffset(w, r, api.logger)
+	if err != nil {
+		return
+	}
+
+	versions, err := api.core.BannerVersions(id, limit, offset)
+	if errors.Is(err, variables.ErrBannerNotFound) {
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.BannerNotFoundError, err, api.logger)
+		return
+	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+		return
+	}
+
+	util.SendResponse(w, r, http.StatusOK, versions, variables.StatusOkMessage, nil, api.logger)
+}
+
+func (api *API) BannerRollback(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodPost {
+		util.SendResponse(w, r, http.StatusMethodNotAllowed, nil, variables.StatusMethodNotAllowedError, nil, api.logger)
+		return
+	}
+
+	var rollback communication.RollbackRequest
+	err := util.GetRequestBody(w, r, &rollback, api.logger)
+	if err != nil {
+		return
+	}
+
+	if rollback.VersionId < 1 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.VersionIdError, nil, api.logger)
+		return
+	}
+
+	err = api.core.RollbackBanner(id, rollback.VersionId)
+	var validation *variables.ContentValidation
+	if errors.As(err, &validation) {
+		api.sendContentValidation(w, r, validation)
+		return
+	}
+	if errors.Is(err, variables.ErrBannerNotFound) {
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.BannerNotFoundError, err, api.logger)
+		return
+	}
+	if errors.Is(err, variables.ErrVersionNotFound) {
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.VersionNotFoundError, err, api.logger)
+		return
+	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+		return
+	}
+
+	util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+}
+
+func (api *API) BannerStats(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		util.SendResponse(w, r, http.StatusMethodNotAllowed, nil, variables.StatusMethodNotAllowedError, nil, api.logger)
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	for _, day := range []string{from, to} {
+		if day == "" {
+			continue
+		}
+		_, err := time.Parse(variables.StatsDateLayout, day)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.StatsDateError, err, api.logger)
+			return
+		}
+	}
+
+	stats, err := api.core.BannerStats(id, from, to)
+	if errors.Is(err, variables.ErrBannerNotFound) {
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.BannerNotFoundError, err, api.logger)
+		return
+	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+		return
+	}
+
+	util.SendResponse(w, r, http.StatusOK, stats, variables.StatusOkMessage, nil, api.logger)
+}
+
+func (api *API) sendConflict(w http.ResponseWriter, r *http.Request, conflict *variables.BannerConflict) {
+	response := communication.ConflictResponse{
+		Error:     variables.BannerConflictError,
+		BannerIDs: conflict.BannerIDs,
+	}
+	util.SendResponse(w, r, http.StatusConflict, response, variables.BannerConflictError, conflict, api.logger)
+}
+
+func (api *API) sendContentValidation(w http.ResponseWriter, r *http.Request, validation *variables.ContentValidation) {
+	response := communication.ValidationResponse{
+		Error:  variables.ContentValidationError,
+		Fields: validation.Fields,
+	}
+	util.SendResponse(w, r, http.StatusUnprocessableEntity, response, variables.ContentValidationError, validation, api.logger)
+}
+
+func (api *API) sendUnknownReferences(w http.ResponseWriter, r *http.Request, unknown *variables.UnknownReferences) {
+	response := communication.UnknownReferencesResponse{
+		Error:     variables.UnknownReferencesError,
+		FeatureID: unknown.FeatureID,
+		TagIDs:    unknown.TagIDs,
+	}
+	util.SendResponse(w, r, http.StatusUnprocessableEntity, response, variables.UnknownReferencesError, unknown, api.logger)
+}
+
+func getName(w http.ResponseWriter, r *http.Request, logger *slog.Logger) (string, error) {
+	var request communication.NameRequest
+	err := util.GetRequestBody(w, r, &request, logger)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(request.Name)
+	err = util.ValidateStringSize(name, variables.MinNameSize, variables.MaxNameSize, variables.NameError, logger)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.NameError, err, logger)
+		return "", err
+	}
+
+	return name, nil
+}
+
+func getLimitOffset(w http.ResponseWriter, r *http.Request, logger *slog.Logger) (int64, int64, error) {
+	limit := int64(variables.PageSize)
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		lim, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || lim < 1 {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.InvalidLimit, err, logger)
+			return 0, 0, fmt.Errorf(variables.InvalidLimit)
+		}
+		limit = min(lim, variables.MaxPageSize)
+	}
+
+	var offset int64
+	offsetStr := r.URL.Query().Get("offset")
+	if offsetStr != "" {
+		off, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || off < 0 {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.InvalidOffset, err, logger)
+			return 0, 0, fmt.Errorf(variables.InvalidOffset)
+		}
+		offset = off
+	}
+
+	return limit, offset, nil
+}
+
+// bannerFromRequest checks a banner create request and converts it. On failure
+// it returns the message to report instead.
+func bannerFromRequest(request communication.BannerRequest) (models.Banner, string) {
+	if !util.IsJsonObject(request.Content) {
+		return models.Banner{}, variables.ContentError
+	}
+
+	if !uniqueTagIDs(request.TagIds) {
+		return models.Banner{}, variables.DuplicateTagIdsError
+	}
+
+	if !validVariants(request.Variants) {
+		return models.Banner{}, variables.VariantsError
+	}
+
+	if request.StartsAt != nil && request.EndsAt != nil && !request.EndsAt.After(*request.StartsAt) {
+		return models.Banner{}, variables.ScheduleError
 	}
+
+	isActive := true
+	if request.IsActive != nil {
+		isActive = *request.IsActive
+	}
+
+	return models.Banner{
+		TagIDs:    request.TagIds,
+		FeatureID: request.FeatureId,
+		Content:   request.Content,
+		Variants:  request.Variants,
+		IsActive:  isActive,
+		StartsAt:  request.StartsAt,
+		EndsAt:    request.EndsAt,
+	}, ""
+}
+
+// uniqueTagIDs reports whether no tag is listed twice. Duplicates would hit
+// the banner_tag primary key.
+func uniqueTagIDs(tagIDs []int64) bool {
+	seen := make(map[int64]bool, len(tagIDs))
+	for _, tagID := range tagIDs {
+		if seen[tagID] {
+			return false
+		}
+		seen[tagID] = true
+	}
+	return true
+}
+
+// validVariants reports whether every variant has a unique non-empty name, a
+// positive weight and a JSON object as content.
+func validVariants(variants []models.BannerVariant) bool {
+	names := make(map[string]bool, len(variants))
+	for _, variant := range variants {
+		if strings.TrimSpace(variant.Name) == "" || names[variant.Name] {
+			return false
+		}
+		if variant.Weight < 1 || !util.IsJsonObject(variant.Content) {
+			return false
+		}
+		names[variant.Name] = true
+	}
+	return true
+}
+
+// encodeCursor packs the cursor into an opaque URL-safe string.
+func encodeCursor(cursor models.BannerCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (*models.BannerCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor models.BannerCursor
+	err = json.Unmarshal(raw, &cursor)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.BannerID < 1 || cursor.UpdatedAt.IsZero() {
+		return nil, errors.New(variables.CursorError)
+	}
+
+	return &cursor, nil
+}
+
+// getSort reads the list order. Banners are sorted by id in ascending order
+// unless asked otherwise.
+func getSort(field string, order string) (models.BannerSort, error) {
+	sort := models.BannerSort{Field: variables.BannerSortID}
+	switch field {
+	case "":
+	case variables.BannerSortID, variables.BannerSortCreatedAt, variables.BannerSortUpdatedAt:
+		sort.Field = field
+	default:
+		return models.BannerSort{}, errors.New(variables.SortError)
+	}
+
+	switch order {
+	case "", variables.SortOrderAsc:
+	case variables.SortOrderDesc:
+		sort.Descending = true
+	default:
+		return models.BannerSort{}, errors.New(variables.SortError)
+	}
+
+	return sort, nil
 }
@@ -0,0 +1,180 @@
+package delivery
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/util"
+	"avito-track/pkg/variables"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBannerETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		banner models.Banner
+		want   string
+	}{
+		{name: "plain", banner: models.Banner{BannerID: 1, VersionID: 2}, want: `"1-2"`},
+		{name: "variant", banner: models.Banner{BannerID: 1, VersionID: 2, Variant: "a"}, want: `"1-2-a"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := bannerETag(&test.banner)
+			if got != test.want {
+				t.Errorf("bannerETag() = %s, want %s", got, test.want)
+			}
+			if !util.ETagMatches(got, got) {
+				t.Errorf("ETagMatches(%s, %s) = false, want true", got, got)
+			}
+		})
+	}
+}
+
+func TestBannerETagChanges(t *testing.T) {
+	base := models.Banner{BannerID: 1, VersionID: 2, Variant: "a"}
+	changed := []models.Banner{
+		{BannerID: 3, VersionID: 2, Variant: "a"},
+		{BannerID: 1, VersionID: 3, Variant: "a"},
+		{BannerID: 1, VersionID: 2, Variant: "b"},
+		{BannerID: 1, VersionID: 2},
+	}
+
+	etag := bannerETag(&base)
+	for _, banner := range changed {
+		if other := bannerETag(&banner); other == etag {
+			t.Errorf("bannerETag(%+v) = %s, same as for %+v", banner, other, base)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := models.BannerCursor{
+		UpdatedAt: time.Date(2024, 5, 1, 9, 30, 15, 123456000, time.FixedZone("MSK", 3*60*60)),
+		BannerID:  42,
+	}
+
+	encoded := encodeCursor(cursor)
+	decoded, err := decodeCursor(encoded)
+	if err != nil {
+		t.Fatalf("decodeCursor(%q) error = %v", encoded, err)
+	}
+	if decoded.BannerID != cursor.BannerID || !decoded.UpdatedAt.Equal(cursor.UpdatedAt) {
+		t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v", cursor, *decoded)
+	}
+}
+
+func TestDecodeCursorRejectsTampered(t *testing.T) {
+	encoded := encodeCursor(models.BannerCursor{UpdatedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), BannerID: 42})
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "truncated", cursor: encoded[:len(encoded)-3]},
+		{name: "padded", cursor: encoded + "="},
+		{name: "standard alphabet", cursor: "+/" + encoded},
+		{name: "not json", cursor: encode("42")},
+		{name: "wrong types", cursor: encode(`{"updated_at":42,"banner_id":"42"}`)},
+		{name: "no banner", cursor: encode(`{"updated_at":"2024-05-01T00:00:00Z"}`)},
+		{name: "negative banner", cursor: encode(`{"updated_at":"2024-05-01T00:00:00Z","banner_id":-1}`)},
+		{name: "no time", cursor: encode(`{"banner_id":42}`)},
+		{name: "empty object", cursor: encode(`{}`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursor, err := decodeCursor(test.cursor)
+			if err == nil {
+				t.Errorf("decodeCursor(%q) = %+v, want error", test.cursor, *cursor)
+			}
+		})
+	}
+}
+
+func TestGetSort(t *testing.T) {
+	tests := []struct {
+		field   string
+		order   string
+		want    models.BannerSort
+		wantErr bool
+	}{
+		{field: "", order: "", want: models.BannerSort{Field: variables.BannerSortID}},
+		{field: "", order: "desc", want: models.BannerSort{Field: variables.BannerSortID, Descending: true}},
+		{field: "created_at", order: "", want: models.BannerSort{Field: variables.BannerSortCreatedAt}},
+		{field: "updated_at", order: "asc", want: models.BannerSort{Field: variables.BannerSortUpdatedAt}},
+		{field: "id", order: "desc", want: models.BannerSort{Field: variables.BannerSortID, Descending: true}},
+		{field: "content", order: "", wantErr: true},
+		{field: "id", order: "DESC", wantErr: true},
+		{field: "id", order: "down", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.field+"/"+test.order, func(t *testing.T) {
+			got, err := getSort(test.field, test.order)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("getSort(%q, %q) = %+v, want error", test.field, test.order, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getSort(%q, %q) error = %v", test.field, test.order, err)
+			}
+			if got != test.want {
+				t.Errorf("getSort(%q, %q) = %+v, want %+v", test.field, test.order, got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetLimitOffset(t *testing.T) {
+	tests := []struct {
+		query   string
+		limit   int64
+		offset  int64
+		wantErr bool
+	}{
+		{query: "", limit: variables.PageSize, offset: 0},
+		{query: "limit=5&offset=20", limit: 5, offset: 20},
+		{query: "limit=100", limit: 100, offset: 0},
+		{query: "limit=101", limit: variables.MaxPageSize, offset: 0},
+		{query: "limit=1000000", limit: variables.MaxPageSize, offset: 0},
+		{query: "limit=0", wantErr: true},
+		{query: "limit=ten", wantErr: true},
+		{query: "offset=-1", wantErr: true},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/banner?"+test.query, nil)
+
+			limit, offset, err := getLimitOffset(w, r, logger)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("getLimitOffset(%q) = %d, %d, want error", test.query, limit, offset)
+				}
+				if w.Code != http.StatusBadRequest {
+					t.Errorf("getLimitOffset(%q) status = %d, want %d", test.query, w.Code, http.StatusBadRequest)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getLimitOffset(%q) error = %v", test.query, err)
+			}
+			if limit != test.limit || offset != test.offset {
+				t.Errorf("getLimitOffset(%q) = %d, %d, want %d, %d", test.query, limit, offset, test.limit, test.offset)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package delivery
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/util"
+	"avito-track/pkg/variables"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (api *API) Features(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		limit, offset, err := getLimitOffset(w, r, api.logger)
+		if err != nil {
+			return
+		}
+
+		features, err := api.core.GetFeatures(limit, offset)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, features, variables.StatusOkMessage, nil, api.logger)
+	case http.MethodPost:
+		name, err := getName(w, r, api.logger)
+		if err != nil {
+			return
+		}
+
+		id, err := api.core.AddFeature(name)
+		if errors.Is(err, variables.ErrFeatureExists) {
+			util.SendResponse(w, r, http.StatusConflict, nil, variables.FeatureExistsError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusCreated, models.Feature{FeatureID: id, Name: name}, variables.StatusOkMessage, nil, api.logger)
+	}
+}
+
+func (api *API) FeatureSettings(w http.ResponseWriter, r *http.Request) {
+	idStr, subresource, _ := strings.Cut(r.URL.Path[len("/api/v1/feature/"):], "/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id < 1 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.FeatureIdError, err, api.logger)
+		return
+	}
+
+	switch subresource {
+	case "":
+		api.FeatureItem(w, r, id)
+	case variables.FeatureSchemaPath:
+		api.FeatureSchema(w, r, id)
+	default:
+		util.SendResponse(w, r, http.StatusNotFound, nil, variables.StatusNotFoundError, nil, api.logger)
+	}
+}
+
+func (api *API) FeatureItem(w http.ResponseWriter, r *http.Request, id int64) {
+	switch r.Method {
+	case http.MethodPatch:
+		name, err := getName(w, r, api.logger)
+		if err != nil {
+			return
+		}
+
+		err = api.core.RenameFeature(id, name)
+		if errors.Is(err, variables.ErrFeatureNotFound) {
+			util.SendResponse(w, r, http.StatusNotFound, nil, variables.FeatureNotFoundError, err, api.logger)
+			return
+		}
+		if errors.Is(err, variables.ErrFeatureExists) {
+			util.SendResponse(w, r, http.StatusConflict, nil, variables.FeatureExistsError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+	case http.MethodDelete:
+		err := api.core.DeleteFeature(id)
+		if errors.Is(err, variables.ErrFeatureNotFound) {
+			util.SendResponse(w, r, http.StatusNotFound, nil, variables.FeatureNotFoundError, err, api.logger)
+			return
+		}
+		if errors.Is(err, variables.ErrFeatureInUse) {
+			util.SendResponse(w, r, http.StatusConflict, nil, variables.FeatureInUseError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+	default:
+		util.SendResponse(w, r, http.StatusMethodNotAllowed, nil, variables.StatusMethodNotAllowedError, nil, api.logger)
+	}
+}
+
+func (api *API) FeatureSchema(w http.ResponseWriter, r *http.Request, id int64) {
+	switch r.Method {
+	case http.MethodGet:
+		schema, err := api.core.FeatureSchema(id)
+		if errors.Is(err, variables.ErrFeatureNotFound) {
+			util.SendResponse(w, r, http.StatusNotFound, nil, variables.FeatureNotFoundError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+		if schema == nil {
+			util.SendResponse(w, r, http.StatusNotFound, nil, variables.FeatureSchemaNotFoundError, nil, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, schema, variables.StatusOkMessage, nil, api.logger)
+	case http.MethodPut, http.MethodDelete:
+		var schema json.RawMessage
+		if r.Method == http.MethodPut {
+			body, err := io.ReadAll(r.Body)
+			if err != nil || !util.IsJsonObject(body) {
+				util.SendResponse(w, r, http.StatusBadRequest, nil, variables.InvalidSchemaError, err, api.logger)
+				return
+			}
+			schema = body
+		}
+
+		err := api.core.SetFeatureSchema(id, schema)
+		if errors.Is(err, variables.ErrInvalidSchema) {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.InvalidSchemaError, err, api.logger)
+			return
+		}
+		if errors.Is(err, variables.ErrFeatureNotFound) {
+			util.SendResponse(w, r, http.StatusNotFound, nil, variables.FeatureNotFoundError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+	default:
+		util.SendResponse(w, r, http.StatusMethodNotAllowed, nil, variables.StatusMethodNotAllowedError, nil, api.logger)
+	}
+}
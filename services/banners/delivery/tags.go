@@ -0,0 +1,93 @@
+package delivery
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/util"
+	"avito-track/pkg/variables"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+func (api *API) Tags(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		limit, offset, err := getLimitOffset(w, r, api.logger)
+		if err != nil {
+			return
+		}
+
+		tags, err := api.core.GetTags(limit, offset)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, tags, variables.StatusOkMessage, nil, api.logger)
+	case http.MethodPost:
+		name, err := getName(w, r, api.logger)
+		if err != nil {
+			return
+		}
+
+		id, err := api.core.AddTag(name)
+		if errors.Is(err, variables.ErrTagExists) {
+			util.SendResponse(w, r, http.StatusConflict, nil, variables.TagExistsError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusCreated, models.Tag{TagID: id, Name: name}, variables.StatusOkMessage, nil, api.logger)
+	}
+}
+
+func (api *API) TagSettings(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Path[len("/api/v1/tag/"):], 10, 64)
+	if err != nil || id < 1 {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.TagIdError, err, api.logger)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPatch:
+		name, err := getName(w, r, api.logger)
+		if err != nil {
+			return
+		}
+
+		err = api.core.RenameTag(id, name)
+		if errors.Is(err, variables.ErrTagNotFound) {
+			util.SendResponse(w, r, http.StatusNotFound, nil, variables.TagNotFoundError, err, api.logger)
+			return
+		}
+		if errors.Is(err, variables.ErrTagExists) {
+			util.SendResponse(w, r, http.StatusConflict, nil, variables.TagExistsError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+	case http.MethodDelete:
+		err := api.core.DeleteTag(id)
+		if errors.Is(err, variables.ErrTagNotFound) {
+			util.SendResponse(w, r, http.StatusNotFound, nil, variables.TagNotFoundError, err, api.logger)
+			return
+		}
+		if errors.Is(err, variables.ErrTagInUse) {
+			util.SendResponse(w, r, http.StatusConflict, nil, variables.TagInUseError, err, api.logger)
+			return
+		}
+		if err != nil {
+			util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+			return
+		}
+
+		util.SendResponse(w, r, http.StatusOK, nil, variables.StatusOkMessage, nil, api.logger)
+	}
+}
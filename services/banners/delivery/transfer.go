@@ -0,0 +1,359 @@
+package delivery
+
+import (
+	"avito-track/pkg/models"
+	communication "avito-track/pkg/requests"
+	"avito-track/pkg/util"
+	"avito-track/pkg/variables"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errImportHeader = errors.New(variables.ImportHeaderError)
+
+func (api *API) ExportBanners(w http.ResponseWriter, r *http.Request) {
+	format, ok := getTransferFormat(w, r, api.logger)
+	if !ok {
+		return
+	}
+
+	var write func(models.Banner) error
+	var flush func() error
+	switch format {
+	case variables.TransferFormatNDJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(w)
+		write = func(banner models.Banner) error {
+			return encoder.Encode(bannerRecord(banner))
+		}
+		flush = func() error {
+			return nil
+		}
+	case variables.TransferFormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+		writer := csv.NewWriter(w)
+		writer.Write(variables.BannerCSVHeader)
+		write = func(banner models.Banner) error {
+			record, err := bannerCSVRecord(banner)
+			if err != nil {
+				return err
+			}
+			return writer.Write(record)
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=banners."+format)
+	w.WriteHeader(http.StatusOK)
+
+	err := api.core.ExportBanners(write)
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		api.logger.Error(variables.ExportBannersError, "error", err.Error())
+	}
+}
+
+func (api *API) ImportBanners(w http.ResponseWriter, r *http.Request) {
+	format, ok := getTransferFormat(w, r, api.logger)
+	if !ok {
+		return
+	}
+
+	var dryRun bool
+	dryRunStr := r.URL.Query().Get("dry_run")
+	if dryRunStr != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			util.SendResponse(w, r, http.StatusBadRequest, nil, variables.DryRunError, err, api.logger)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, variables.MaxImportSize)
+
+	var requests []communication.BannerRequest
+	var rowErrors []models.ImportError
+	var err error
+	switch format {
+	case variables.TransferFormatNDJSON:
+		requests, rowErrors, err = readNDJSONBanners(body)
+	case variables.TransferFormatCSV:
+		requests, rowErrors, err = readCSVBanners(body)
+	}
+	if errors.Is(err, errImportHeader) {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.ImportHeaderError, err, api.logger)
+		return
+	}
+	if err != nil {
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.StatusBadRequestError, err, api.logger)
+		return
+	}
+
+	undecoded := make(map[int]bool, len(rowErrors))
+	for _, rowError := range rowErrors {
+		undecoded[rowError.Row] = true
+	}
+
+	banners := make([]models.Banner, 0, len(requests))
+	rows := make([]int, 0, len(requests))
+	for i, request := range requests {
+		if undecoded[i+1] {
+			continue
+		}
+		banner, message := bannerFromRequest(request)
+		if message != "" {
+			rowErrors = append(rowErrors, models.ImportError{Row: i + 1, Message: message})
+			continue
+		}
+		banners = append(banners, banner)
+		rows = append(rows, i+1)
+	}
+
+	// The rows that were read are still checked so that every failed row is
+	// reported, but nothing is stored when some rows could not be read.
+	result, err := api.core.ImportBanners(banners, dryRun || len(rowErrors) > 0)
+	if err != nil {
+		util.SendResponse(w, r, http.StatusInternalServerError, nil, variables.StatusInternalServerError, err, api.logger)
+		return
+	}
+
+	result.DryRun = dryRun
+	for _, rowError := range result.Errors {
+		rowError.Row = rows[rowError.Row-1]
+		rowErrors = append(rowErrors, rowError)
+	}
+	sort.SliceStable(rowErrors, func(i, j int) bool {
+		return rowErrors[i].Row < rowErrors[j].Row
+	})
+	result.Errors = make([]models.ImportError, 0, len(rowErrors))
+	result.Errors = append(result.Errors, rowErrors...)
+
+	if len(result.Errors) > 0 && !dryRun {
+		util.SendResponse(w, r, http.StatusUnprocessableEntity, result, variables.ImportRowsError, nil, api.logger)
+		return
+	}
+
+	util.SendResponse(w, r, http.StatusOK, result, variables.StatusOkMessage, nil, api.logger)
+}
+
+func getTransferFormat(w http.ResponseWriter, r *http.Request, logger *slog.Logger) (string, bool) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		return variables.TransferFormatNDJSON, true
+	case variables.TransferFormatNDJSON, variables.TransferFormatCSV:
+		return format, true
+	default:
+		util.SendResponse(w, r, http.StatusBadRequest, nil, variables.TransferFormatError, nil, logger)
+		return "", false
+	}
+}
+
+func bannerRecord(banner models.Banner) communication.BannerRecord {
+	return communication.BannerRecord{
+		BannerId:  banner.BannerID,
+		TagIds:    banner.TagIDs,
+		FeatureId: banner.FeatureID,
+		Content:   banner.Content,
+		Variants:  banner.Variants,
+		IsActive:  banner.IsActive,
+		StartsAt:  banner.StartsAt,
+		EndsAt:    banner.EndsAt,
+	}
+}
+
+func bannerCSVRecord(banner models.Banner) ([]string, error) {
+	tagIDs := make([]string, 0, len(banner.TagIDs))
+	for _, tagID := range banner.TagIDs {
+		tagIDs = append(tagIDs, strconv.FormatInt(tagID, 10))
+	}
+
+	var variants string
+	if len(banner.Variants) > 0 {
+		raw, err := json.Marshal(banner.Variants)
+		if err != nil {
+			return nil, err
+		}
+		variants = string(raw)
+	}
+
+	return []string{
+		strconv.FormatInt(banner.BannerID, 10),
+		strconv.FormatInt(banner.FeatureID, 10),
+		strings.Join(tagIDs, ","),
+		string(banner.Content),
+		variants,
+		strconv.FormatBool(banner.IsActive),
+		formatCSVTime(banner.StartsAt),
+		formatCSVTime(banner.EndsAt),
+	}, nil
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// readNDJSONBanners decodes one banner per non-empty line. Lines that cannot be
+// decoded are reported as row errors.
+func readNDJSONBanners(body io.Reader) ([]communication.BannerRequest, []models.ImportError, error) {
+	var requests []communication.BannerRequest
+	var rowErrors []models.ImportError
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), variables.MaxImportSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var request communication.BannerRequest
+		err := json.Unmarshal(line, &request)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportError{Row: len(requests) + 1, Message: variables.ImportRecordError})
+		}
+		requests = append(requests, request)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return requests, rowErrors, nil
+}
+
+// readCSVBanners decodes banners from CSV with a header row naming the
+// columns. Records that cannot be decoded are reported as row errors.
+func readCSVBanners(body io.Reader) ([]communication.BannerRequest, []models.ImportError, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, errImportHeader
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"feature_id", "tag_ids", "content"} {
+		if _, found := columns[name]; !found {
+			return nil, nil, errImportHeader
+		}
+	}
+
+	var requests []communication.BannerRequest
+	var rowErrors []models.ImportError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return nil, nil, err
+		}
+
+		var request communication.BannerRequest
+		if err == nil {
+			request, err = csvBannerRequest(record, columns)
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportError{Row: len(requests) + 1, Message: variables.ImportRecordError})
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, rowErrors, nil
+}
+
+func csvBannerRequest(record []string, columns map[string]int) (communication.BannerRequest, error) {
+	field := func(name string) string {
+		i, found := columns[name]
+		if !found || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var request communication.BannerRequest
+	var err error
+
+	request.FeatureId, err = strconv.ParseInt(field("feature_id"), 10, 64)
+	if err != nil {
+		return request, err
+	}
+
+	// Banners without tags are exported with an empty column.
+	request.TagIds = []int64{}
+	if tagIDs := field("tag_ids"); tagIDs != "" {
+		for _, rawTagID := range strings.Split(tagIDs, ",") {
+			tagID, err := strconv.ParseInt(strings.TrimSpace(rawTagID), 10, 64)
+			if err != nil {
+				return request, err
+			}
+			request.TagIds = append(request.TagIds, tagID)
+		}
+	}
+
+	request.Content = json.RawMessage(field("content"))
+
+	if variants := field("variants"); variants != "" {
+		err = json.Unmarshal([]byte(variants), &request.Variants)
+		if err != nil {
+			return request, err
+		}
+	}
+
+	if isActive := field("is_active"); isActive != "" {
+		value, err := strconv.ParseBool(isActive)
+		if err != nil {
+			return request, err
+		}
+		request.IsActive = &value
+	}
+
+	request.StartsAt, err = parseCSVTime(field("starts_at"))
+	if err != nil {
+		return request, err
+	}
+
+	request.EndsAt, err = parseCSVTime(field("ends_at"))
+	if err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+func parseCSVTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,111 @@
+package delivery
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCSVRoundTrip(t *testing.T) {
+	startsAt := time.Date(2024, 5, 1, 9, 0, 0, 0, time.FixedZone("MSK", 3*60*60))
+	endsAt := startsAt.Add(30 * 24 * time.Hour)
+
+	tests := []struct {
+		name   string
+		banner models.Banner
+	}{
+		{
+			name: "no tags",
+			banner: models.Banner{
+				BannerID:  1,
+				FeatureID: 2,
+				TagIDs:    []int64{},
+				Content:   json.RawMessage(`{"title":"no tags"}`),
+				IsActive:  true,
+			},
+		},
+		{
+			name: "tags and schedule",
+			banner: models.Banner{
+				BannerID:  2,
+				FeatureID: 3,
+				TagIDs:    []int64{4, 5},
+				Content:   json.RawMessage(`{"title":"scheduled, \"quoted\""}`),
+				IsActive:  false,
+				StartsAt:  &startsAt,
+				EndsAt:    &endsAt,
+			},
+		},
+		{
+			name: "variants",
+			banner: models.Banner{
+				BannerID:  3,
+				FeatureID: 3,
+				TagIDs:    []int64{6},
+				Content:   json.RawMessage(`{"title":"base"}`),
+				Variants: []models.BannerVariant{
+					{Name: "a", Weight: 70, Content: json.RawMessage(`{"title":"a"}`)},
+					{Name: "b", Weight: 30, Content: json.RawMessage(`{"title":"b"}`)},
+				},
+				IsActive: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var exported bytes.Buffer
+			writer := csv.NewWriter(&exported)
+			writer.Write(variables.BannerCSVHeader)
+			record, err := bannerCSVRecord(test.banner)
+			if err != nil {
+				t.Fatalf("bannerCSVRecord() error = %v", err)
+			}
+			writer.Write(record)
+			writer.Flush()
+
+			requests, rowErrors, err := readCSVBanners(&exported)
+			if err != nil || len(rowErrors) > 0 {
+				t.Fatalf("readCSVBanners() = %v, %v", rowErrors, err)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("readCSVBanners() read %d rows, want 1", len(requests))
+			}
+
+			imported, message := bannerFromRequest(requests[0])
+			if message != "" {
+				t.Fatalf("bannerFromRequest() = %q", message)
+			}
+
+			want := test.banner
+			want.BannerID = 0
+			if !reflect.DeepEqual(imported.TagIDs, want.TagIDs) {
+				t.Errorf("tag ids = %#v, want %#v", imported.TagIDs, want.TagIDs)
+			}
+			if imported.FeatureID != want.FeatureID || imported.IsActive != want.IsActive {
+				t.Errorf("imported = %+v, want %+v", imported, want)
+			}
+			if string(imported.Content) != string(want.Content) {
+				t.Errorf("content = %s, want %s", imported.Content, want.Content)
+			}
+			if !reflect.DeepEqual(imported.Variants, want.Variants) {
+				t.Errorf("variants = %+v, want %+v", imported.Variants, want.Variants)
+			}
+			if !sameTime(imported.StartsAt, want.StartsAt) || !sameTime(imported.EndsAt, want.EndsAt) {
+				t.Errorf("schedule = %v - %v, want %v - %v", imported.StartsAt, imported.EndsAt, want.StartsAt, want.EndsAt)
+			}
+		})
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -4,10 +4,16 @@ import (
 	"avito-track/pkg/models"
 	"avito-track/pkg/variables"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx"
 	_ "github.com/jackc/pgx/stdlib"
 	"github.com/lib/pq"
 	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +21,20 @@ type BannerRepository struct {
 	db *sql.DB
 }
 
+const liveCondition = "(b.starts_at IS NULL OR b.starts_at <= NOW()) AND (b.ends_at IS NULL OR b.ends_at > NOW())"
+
+var sortColumns = map[string]string{
+	variables.BannerSortID:        "b.id",
+	variables.BannerSortCreatedAt: "b.created_at",
+	variables.BannerSortUpdatedAt: "v.updated_at",
+}
+
+var scheduleConditions = map[string]string{
+	variables.BannerStatusScheduled: "b.starts_at > NOW()",
+	variables.BannerStatusLive:      liveCondition,
+	variables.BannerStatusExpired:   "b.ends_at <= NOW()",
+}
+
 func GetBannerRepository(configDatabase variables.RelationalDataBaseConfig, logger *slog.Logger) (*BannerRepository, error) {
 	dsn := fmt.Sprintf("user=%s dbname=%s password= %s host=%s port=%d sslmode=%s",
 		configDatabase.User, configDatabase.DbName, configDatabase.Password, configDatabase.Host, configDatabase.Port, configDatabase.Sslmode)
@@ -67,32 +87,126 @@ func (repository *BannerRepository) pingDb(timer uint32, logger *slog.Logger) er
 	return fmt.Errorf(variables.SqlMaxPingRetriesError, err.Error())
 }
 
-func (repository *BannerRepository) GetBanners(userRole string, featureID int64, tagIDs []int64, limit, offset int64) ([]models.Banner, error) {
+// GetBanners lists banners matching the filter with their current version,
+// one row per banner, in the sort order.
+func (repository *BannerRepository) GetBanners(filter models.BannerFilter, sort models.BannerSort, limit, offset int64) ([]models.Banner, error) {
+	condition, args := bannerFilterCondition(filter)
+	args = append(args, limit, offset)
+
+	direction := "ASC"
+	if sort.Descending {
+		direction = "DESC"
+	}
+
+	column, found := sortColumns[sort.Field]
+	if !found {
+		column = sortColumns[variables.BannerSortID]
+	}
+
+	query := bannerListQuery + `
+		WHERE ` + condition + `
+		GROUP BY b.id, v.id
+		ORDER BY ` + column + ` ` + direction + `, b.id ` + direction + `
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	return repository.queryBanners(query, args...)
+}
+
+// GetBannersAfter lists banners matching the filter newest first, starting
+// right after the cursor. A nil cursor starts from the newest banner.
+func (repository *BannerRepository) GetBannersAfter(filter models.BannerFilter, after *models.BannerCursor, limit int64) ([]models.Banner, error) {
+	condition, args := bannerFilterCondition(filter)
+	if after != nil {
+		args = append(args, after.UpdatedAt, after.BannerID)
+		condition += " AND (v.updated_at, b.id) < ($" + strconv.Itoa(len(args)-1) + ", $" + strconv.Itoa(len(args)) + ")"
+	}
+	args = append(args, limit)
+
+	query := bannerListQuery + `
+		WHERE ` + condition + `
+		GROUP BY b.id, v.id
+		ORDER BY v.updated_at DESC, b.id DESC
+		LIMIT $` + strconv.Itoa(len(args))
+
+	return repository.queryBanners(query, args...)
+}
+
+func (repository *BannerRepository) CountFilteredBanners(filter models.BannerFilter) (int64, error) {
+	condition, args := bannerFilterCondition(filter)
+
+	var total int64
 	query := `
-		SELECT b.id, b.feature_id, v.is_active, b.created_at, v.updated_at, v.data, array_agg(bt.tag_id)
+		SELECT COUNT(*)
 		FROM banners b
-		INNER JOIN versions v ON b.id = v.banner_id
-		INNER JOIN banner_tag bt ON b.id = bt.banner_id
-		WHERE b.feature_id = $1 AND bt.tag_id = ANY($2)
-		GROUP BY b.id, v.data, v.is_active, v.updated_at
-		LIMIT $3 OFFSET $4
-	`
-	rows, err := repository.db.Query(query, featureID, pq.Array(tagIDs), limit, offset)
+		INNER JOIN versions v ON b.id = v.banner_id AND v.is_active = TRUE
+		WHERE ` + condition
+	err := repository.db.QueryRow(query, args...).Scan(&total)
+	if pgErrorCode(err) == variables.SyntaxErrorCode {
+		return 0, variables.ErrInvalidQuery
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+const bannerListQuery = `
+		SELECT b.id, b.feature_id, b.is_active, b.starts_at, b.ends_at, b.created_at, v.updated_at, v.data, v.variants,
+			COALESCE(array_agg(bt.tag_id ORDER BY bt.tag_id) FILTER (WHERE bt.tag_id IS NOT NULL), '{}')
+		FROM banners b
+		INNER JOIN versions v ON b.id = v.banner_id AND v.is_active = TRUE
+		LEFT JOIN banner_tag bt ON b.id = bt.banner_id`
+
+// bannerFilterCondition builds the WHERE condition over banners b selecting
+// the filter, with its arguments numbered from $1.
+func bannerFilterCondition(filter models.BannerFilter) (string, []any) {
+	conditions := []string{"TRUE"}
+	var args []any
+
+	if filter.FeatureID != 0 {
+		args = append(args, filter.FeatureID)
+		conditions = append(conditions, "b.feature_id = $"+strconv.Itoa(len(args)))
+	}
+	if len(filter.TagIDs) > 0 {
+		args = append(args, pq.Array(filter.TagIDs))
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM banner_tag filter_tag WHERE filter_tag.banner_id = b.id AND filter_tag.tag_id = ANY($"+strconv.Itoa(len(args))+"))")
+	}
+	if filter.ActiveOnly {
+		conditions = append(conditions, "b.is_active")
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, scheduleConditions[filter.Status])
+	}
+	if strings.HasPrefix(filter.Query, "$") {
+		args = append(args, filter.Query)
+		conditions = append(conditions, "v.data @? $"+strconv.Itoa(len(args))+"::jsonpath")
+	} else if filter.Query != "" {
+		args = append(args, filter.Query)
+		conditions = append(conditions, "jsonb_to_tsvector('simple', v.data, '[\"string\"]') @@ websearch_to_tsquery('simple', $"+strconv.Itoa(len(args))+")")
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+func (repository *BannerRepository) queryBanners(query string, args ...any) ([]models.Banner, error) {
+	rows, err := repository.db.Query(query, args...)
+	if pgErrorCode(err) == variables.SyntaxErrorCode {
+		return nil, variables.ErrInvalidQuery
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var banners []models.Banner
+	banners := make([]models.Banner, 0)
 	for rows.Next() {
 		var banner models.Banner
-		err := rows.Scan(&banner.BannerID, &banner.FeatureID, &banner.IsActive, &banner.CreatedAt, &banner.UpdatedAt, &banner.Content, pq.Array(&banner.TagIDs))
+		err := rows.Scan(&banner.BannerID, &banner.FeatureID, &banner.IsActive, &banner.StartsAt, &banner.EndsAt, &banner.CreatedAt, &banner.UpdatedAt, (*[]byte)(&banner.Content), (*variantsColumn)(&banner.Variants), pq.Array(&banner.TagIDs))
 		if err != nil {
 			return nil, err
 		}
-		if userRole == variables.UserRole[0] && banner.IsActive {
-			banners = append(banners, banner)
-		}
+		banners = append(banners, banner)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -102,69 +216,163 @@ func (repository *BannerRepository) GetBanners(userRole string, featureID int64,
 	return banners, nil
 }
 
-func (repository *BannerRepository) AddBanner(tagIds []int64, featureID int64, content string) error {
+func (repository *BannerRepository) AddBanner(banner models.Banner) (int64, error) {
 	tx, err := repository.db.Begin()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	var bannerID int64
-	err = tx.QueryRow("INSERT INTO banners (feature_id) VALUES ($1) RETURNING id", featureID).Scan(&bannerID)
+	bannerID, err := repository.insertBanner(tx, banner)
 	if err != nil {
 		tx.Rollback()
-		return err
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return bannerID, nil
+}
+
+// ImportBanners stores the banners in one transaction. Rows that conflict
+// with existing banners or with earlier rows, or have an invalid schedule, get
+// their error at the same position of the returned slice, and then nothing is
+// stored. Every row runs in a savepoint, so a failed row does not abort the
+// checks of the rows after it. With dryRun the transaction is always rolled
+// back.
+func (repository *BannerRepository) ImportBanners(banners []models.Banner, dryRun bool) ([]error, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return nil, err
 	}
 
-	for _, tagID := range tagIds {
-		_, err := tx.Exec("INSERT INTO banner_tag (banner_id, tag_id) VALUES ($1, $2)", bannerID, tagID)
+	rowErrors := make([]error, len(banners))
+	failed := false
+	for i, banner := range banners {
+		_, err = tx.Exec("SAVEPOINT import_row")
 		if err != nil {
 			tx.Rollback()
-			return err
+			return nil, err
+		}
+
+		_, err = repository.insertBanner(tx, banner)
+		var conflict *variables.BannerConflict
+		if errors.As(err, &conflict) || errors.Is(err, variables.ErrInvalidSchedule) {
+			_, rollbackErr := tx.Exec("ROLLBACK TO SAVEPOINT import_row")
+			if rollbackErr != nil {
+				tx.Rollback()
+				return nil, rollbackErr
+			}
+			rowErrors[i] = err
+			failed = true
+			continue
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		_, err = tx.Exec("RELEASE SAVEPOINT import_row")
+		if err != nil {
+			tx.Rollback()
+			return nil, err
 		}
 	}
 
-	_, err = tx.Exec("INSERT INTO versions (banner_id, data) VALUES ($1, $2)", bannerID, content)
-	if err != nil {
-		tx.Rollback()
-		return err
+	if failed || dryRun {
+		return rowErrors, tx.Rollback()
 	}
 
 	err = tx.Commit()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return rowErrors, nil
 }
 
-func (repository *BannerRepository) UserBanner(tagID int64, featureID int64, useLastRevision bool) (*models.Banner, error) {
-	var query string
-	if useLastRevision {
-		query = `
-            SELECT b.id, b.feature_id, v.is_active, MAX(b.created_at), v.updated_at, v.data, array_agg(bt.tag_id)
-            FROM banners b
-            INNER JOIN versions v ON b.id = v.banner_id
-            LEFT JOIN banner_tag bt ON b.id = bt.banner_id
-            WHERE b.feature_id = $1 AND bt.tag_id = $2 AND v.is_active = TRUE
-            GROUP BY b.id, b.feature_id, v.is_active, b.created_at, v.data, v.updated_at
-            ORDER BY v.updated_at DESC
-            LIMIT 1
-        `
-	} else {
-		query = `
-            SELECT b.id, b.feature_id, v.is_active, b.created_at, v.updated_at, v.data, array_agg(bt.tag_id)
-            FROM banners b
-            INNER JOIN versions v ON b.id = v.banner_id
-            LEFT JOIN banner_tag bt ON b.id = bt.banner_id
-            WHERE b.feature_id = $1 AND bt.tag_id = $2 AND v.is_active = TRUE AND v.updated_at <= NOW() - INTERVAL '5 MINUTES'
-            GROUP BY b.id, v.is_active, b.created_at, v.data, v.updated_at
-        `
+// insertBanner stores the banner with its tags and first version inside tx
+// and returns the new banner id.
+func (repository *BannerRepository) insertBanner(tx *sql.Tx, banner models.Banner) (int64, error) {
+	err := checkConflicts(tx, 0, banner.FeatureID, banner.TagIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	var bannerID int64
+	err = tx.QueryRow("INSERT INTO banners (feature_id, is_active, starts_at, ends_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		banner.FeatureID, banner.IsActive, banner.StartsAt, banner.EndsAt).Scan(&bannerID)
+	if pgErrorCode(err) == variables.CheckViolationCode {
+		return 0, variables.ErrInvalidSchedule
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, tagID := range banner.TagIDs {
+		_, err := tx.Exec("INSERT INTO banner_tag (banner_id, feature_id, tag_id) VALUES ($1, $2, $3)", bannerID, banner.FeatureID, tagID)
+		if err != nil {
+			return 0, repository.conflictOrError(err, 0, banner.FeatureID, banner.TagIDs)
+		}
+	}
+
+	_, err = tx.Exec("INSERT INTO versions (banner_id, data, variants) VALUES ($1, $2, $3)", bannerID, string(banner.Content), variantsColumn(banner.Variants))
+	if err != nil {
+		return 0, err
+	}
+
+	return bannerID, nil
+}
+
+// ExportBanners passes every banner with its tags and active content to write,
+// one at a time, in id order.
+func (repository *BannerRepository) ExportBanners(write func(models.Banner) error) error {
+	query := bannerListQuery + `
+		GROUP BY b.id, v.id
+		ORDER BY b.id
+	`
+	rows, err := repository.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var banner models.Banner
+		err := rows.Scan(&banner.BannerID, &banner.FeatureID, &banner.IsActive, &banner.StartsAt, &banner.EndsAt, &banner.CreatedAt, &banner.UpdatedAt, (*[]byte)(&banner.Content), (*variantsColumn)(&banner.Variants), pq.Array(&banner.TagIDs))
+		if err != nil {
+			return err
+		}
+
+		err = write(banner)
+		if err != nil {
+			return err
+		}
 	}
 
+	return rows.Err()
+}
+
+// UserBanner returns the active banner of the pair whatever its schedule. The
+// caller checks that it is live, so a cached banner starts and ends on time.
+func (repository *BannerRepository) UserBanner(tagID int64, featureID int64) (*models.Banner, error) {
+	query := `
+		SELECT b.id, v.id, b.feature_id, b.is_active, b.starts_at, b.ends_at, b.created_at, v.updated_at, v.data, v.variants, array_agg(bt.tag_id)
+		FROM banners b
+		INNER JOIN versions v ON b.id = v.banner_id
+		LEFT JOIN banner_tag bt ON b.id = bt.banner_id
+		WHERE b.feature_id = $1 AND bt.tag_id = $2 AND v.is_active = TRUE AND b.is_active = TRUE
+		GROUP BY b.id, v.id
+		ORDER BY v.updated_at DESC
+		LIMIT 1
+	`
+
 	row := repository.db.QueryRow(query, featureID, tagID)
 
 	var banner models.Banner
-	err := row.Scan(&banner.BannerID, &banner.FeatureID, &banner.IsActive, &banner.CreatedAt, &banner.UpdatedAt, &banner.Content, pq.Array(&banner.TagIDs))
+	err := row.Scan(&banner.BannerID, &banner.VersionID, &banner.FeatureID, &banner.IsActive, &banner.StartsAt, &banner.EndsAt, &banner.CreatedAt, &banner.UpdatedAt, (*[]byte)(&banner.Content), (*variantsColumn)(&banner.Variants), pq.Array(&banner.TagIDs))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -175,42 +383,151 @@ func (repository *BannerRepository) UserBanner(tagID int64, featureID int64, use
 	return &banner, nil
 }
 
-func (repository *BannerRepository) UpdateBanner(id int64, tagIds []int64, featureID int64, content string) error {
-	tx, err := repository.db.Begin()
+func (repository *BannerRepository) GetBanner(id int64) (*models.Banner, error) {
+	query := `
+		SELECT b.id, b.feature_id, b.is_active, b.starts_at, b.ends_at, b.created_at, v.updated_at, v.data, v.variants,
+			COALESCE(array_agg(bt.tag_id) FILTER (WHERE bt.tag_id IS NOT NULL), '{}')
+		FROM banners b
+		INNER JOIN versions v ON b.id = v.banner_id AND v.is_active = TRUE
+		LEFT JOIN banner_tag bt ON b.id = bt.banner_id
+		WHERE b.id = $1
+		GROUP BY b.id, v.id
+	`
+	row := repository.db.QueryRow(query, id)
+
+	var banner models.Banner
+	err := row.Scan(&banner.BannerID, &banner.FeatureID, &banner.IsActive, &banner.StartsAt, &banner.EndsAt, &banner.CreatedAt, &banner.UpdatedAt, (*[]byte)(&banner.Content), (*variantsColumn)(&banner.Variants), pq.Array(&banner.TagIDs))
+	if err == sql.ErrNoRows {
+		return nil, variables.ErrBannerNotFound
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = tx.Exec("UPDATE versions SET is_active = FALSE WHERE banner_id = $1 AND is_active = TRUE", id)
+	return &banner, nil
+}
+
+func (repository *BannerRepository) UpdateBanner(id int64, patch models.BannerPatch) error {
+	tx, err := repository.db.Begin()
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
-	_, err = tx.Exec("INSERT INTO versions (banner_id, is_active, data) VALUES ($1, TRUE, $2)", id, content)
-	if err != nil {
+	var featureID int64
+	err = tx.QueryRow("SELECT feature_id FROM banners WHERE id = $1 FOR UPDATE", id).Scan(&featureID)
+	if err == sql.ErrNoRows {
 		tx.Rollback()
-		return err
+		return variables.ErrBannerNotFound
 	}
-
-	_, err = tx.Exec("UPDATE banners SET feature_id = $1 WHERE id = $2", featureID, id)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	_, err = tx.Exec("DELETE FROM banner_tag WHERE banner_id = $1", id)
-	if err != nil {
-		tx.Rollback()
-		return err
+	if patch.FeatureID != nil {
+		featureID = *patch.FeatureID
+	}
+
+	var tagIDs []int64
+	if patch.TagIDs != nil {
+		tagIDs = *patch.TagIDs
+	} else {
+		err = tx.QueryRow("SELECT COALESCE(array_agg(tag_id), '{}') FROM banner_tag WHERE banner_id = $1", id).Scan(pq.Array(&tagIDs))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if patch.FeatureID != nil || patch.TagIDs != nil {
+		err = checkConflicts(tx, id, featureID, tagIDs)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
-	for _, tagID := range tagIds {
-		_, err = tx.Exec("INSERT INTO banner_tag (banner_id, tag_id) VALUES ($1, $2)", id, tagID)
+	if patch.Content != nil || patch.Variants != nil {
+		var content json.RawMessage
+		var variants []models.BannerVariant
+		err = tx.QueryRow("SELECT data, variants FROM versions WHERE banner_id = $1 AND is_active = TRUE", id).Scan((*[]byte)(&content), (*variantsColumn)(&variants))
 		if err != nil {
 			tx.Rollback()
 			return err
 		}
+
+		if patch.Content != nil {
+			content = *patch.Content
+		}
+		if patch.Variants != nil {
+			variants = *patch.Variants
+		}
+
+		var unchanged bool
+		err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM versions WHERE banner_id = $1 AND is_active = TRUE AND data = $2::jsonb AND variants IS NOT DISTINCT FROM $3::jsonb)",
+			id, string(content), variantsColumn(variants)).Scan(&unchanged)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if !unchanged {
+			err = insertActiveVersion(tx, id, string(content), variants)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	if patch.IsActive != nil {
+		_, err = tx.Exec("UPDATE banners SET is_active = $1 WHERE id = $2", *patch.IsActive, id)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if patch.StartsAt.Set || patch.EndsAt.Set {
+		_, err = tx.Exec(`UPDATE banners SET
+				starts_at = CASE WHEN $1 THEN $2::timestamptz ELSE starts_at END,
+				ends_at = CASE WHEN $3 THEN $4::timestamptz ELSE ends_at END
+			WHERE id = $5`,
+			patch.StartsAt.Set, patch.StartsAt.Time, patch.EndsAt.Set, patch.EndsAt.Time, id)
+		if pgErrorCode(err) == variables.CheckViolationCode {
+			tx.Rollback()
+			return variables.ErrInvalidSchedule
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if patch.TagIDs != nil {
+		_, err = tx.Exec("DELETE FROM banner_tag WHERE banner_id = $1", id)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if patch.FeatureID != nil {
+		_, err = tx.Exec("UPDATE banners SET feature_id = $1 WHERE id = $2", featureID, id)
+		if err != nil {
+			tx.Rollback()
+			return repository.conflictOrError(err, id, featureID, tagIDs)
+		}
+	}
+
+	if patch.TagIDs != nil {
+		for _, tagID := range tagIDs {
+			_, err = tx.Exec("INSERT INTO banner_tag (banner_id, feature_id, tag_id) VALUES ($1, $2, $3)", id, featureID, tagID)
+			if err != nil {
+				tx.Rollback()
+				return repository.conflictOrError(err, id, featureID, tagIDs)
+			}
+		}
 	}
 
 	err = tx.Commit()
@@ -252,3 +569,368 @@ func (repository *BannerRepository) DeleteBanner(id int64) error {
 
 	return nil
 }
+
+func (repository *BannerRepository) BannerVersions(id int64, limit, offset int64) ([]models.BannerVersion, error) {
+	var exists bool
+	err := repository.db.QueryRow("SELECT EXISTS(SELECT 1 FROM banners WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, variables.ErrBannerNotFound
+	}
+
+	query := `
+		SELECT id, banner_id, data, variants, is_active, updated_at
+		FROM versions
+		WHERE banner_id = $1
+		ORDER BY updated_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := repository.db.Query(query, id, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make([]models.BannerVersion, 0)
+	for rows.Next() {
+		var version models.BannerVersion
+		err := rows.Scan(&version.VersionID, &version.BannerID, (*[]byte)(&version.Content), (*variantsColumn)(&version.Variants), &version.IsActive, &version.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// RollbackBanner makes the version active again. validate is called with the
+// content of the version before it is activated and aborts the rollback when
+// it fails.
+func (repository *BannerRepository) RollbackBanner(id int64, versionID int64, validate func(featureID int64, content json.RawMessage, variants []models.BannerVariant) error) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var featureID int64
+	err = tx.QueryRow("SELECT feature_id FROM banners WHERE id = $1 FOR UPDATE", id).Scan(&featureID)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return variables.ErrBannerNotFound
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var content string
+	var variants []models.BannerVariant
+	var isActive bool
+	err = tx.QueryRow("SELECT data, variants, is_active FROM versions WHERE id = $1 AND banner_id = $2", versionID, id).Scan(&content, (*variantsColumn)(&variants), &isActive)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return variables.ErrVersionNotFound
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if isActive {
+		return tx.Rollback()
+	}
+
+	err = validate(featureID, json.RawMessage(content), variants)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = insertActiveVersion(tx, id, content, variants)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository *BannerRepository) CountBanners(featureID int64, tagID int64) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM banners b
+		WHERE ($1 = 0 OR b.feature_id = $1)
+			AND ($2 = 0 OR EXISTS (SELECT 1 FROM banner_tag bt WHERE bt.banner_id = b.id AND bt.tag_id = $2))
+	`
+	var count int64
+	err := repository.db.QueryRow(query, featureID, tagID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// DeleteBannersBatch deletes up to batchSize banners matching the filters in a
+// single short transaction. A zero featureID or tagID matches any value.
+func (repository *BannerRepository) DeleteBannersBatch(featureID int64, tagID int64, batchSize int) (int64, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		SELECT b.id
+		FROM banners b
+		WHERE ($1 = 0 OR b.feature_id = $1)
+			AND ($2 = 0 OR EXISTS (SELECT 1 FROM banner_tag bt WHERE bt.banner_id = b.id AND bt.tag_id = $2))
+		ORDER BY b.id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(query, featureID, tagID, batchSize)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		err := rows.Scan(&id)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, tx.Rollback()
+	}
+
+	_, err = tx.Exec("DELETE FROM versions WHERE banner_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.Exec("DELETE FROM banner_tag WHERE banner_id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.Exec("DELETE FROM banners WHERE id = ANY($1)", pq.Array(ids))
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(ids)), nil
+}
+
+// PruneVersions removes inactive versions beyond the keepLast newest ones of each
+// banner or older than maxAgeHours. A zero limit disables that rule.
+func (repository *BannerRepository) PruneVersions(keepLast int, maxAgeHours int) (int64, error) {
+	query := `
+		DELETE FROM versions v
+		USING (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY banner_id ORDER BY updated_at DESC, id DESC) AS position
+			FROM versions
+		) ranked
+		WHERE v.id = ranked.id AND v.is_active = FALSE
+			AND (($1 > 0 AND ranked.position > $1) OR ($2 > 0 AND v.updated_at < NOW() - make_interval(hours => $2)))
+	`
+	result, err := repository.db.Exec(query, keepLast, maxAgeHours)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// checkConflicts returns a BannerConflict error when other banners than id
+// already use the feature together with one of the tags.
+func checkConflicts(q queryer, id int64, featureID int64, tagIDs []int64) error {
+	query := `
+		SELECT DISTINCT banner_id
+		FROM banner_tag
+		WHERE feature_id = $1 AND tag_id = ANY($2) AND banner_id <> $3
+		ORDER BY banner_id
+	`
+	rows, err := q.Query(query, featureID, pq.Array(tagIDs), id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var bannerIDs []int64
+	for rows.Next() {
+		var bannerID int64
+		err := rows.Scan(&bannerID)
+		if err != nil {
+			return err
+		}
+		bannerIDs = append(bannerIDs, bannerID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if len(bannerIDs) > 0 {
+		return &variables.BannerConflict{BannerIDs: bannerIDs}
+	}
+
+	return nil
+}
+
+// conflictOrError turns a unique violation caused by a concurrent write into a
+// BannerConflict error. Other errors are returned unchanged.
+func (repository *BannerRepository) conflictOrError(err error, id int64, featureID int64, tagIDs []int64) error {
+	if pgErrorCode(err) != variables.UniqueViolationCode {
+		return err
+	}
+
+	conflictErr := checkConflicts(repository.db, id, featureID, tagIDs)
+	if conflictErr != nil {
+		return conflictErr
+	}
+
+	return err
+}
+
+// CheckReferences returns an UnknownReferences error when the feature or some
+// of the tags do not exist.
+func (repository *BannerRepository) CheckReferences(featureID int64, tagIDs []int64) error {
+	var featureExists bool
+	err := repository.db.QueryRow("SELECT EXISTS(SELECT 1 FROM features WHERE id = $1)", featureID).Scan(&featureExists)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT requested.id
+		FROM unnest($1::integer[]) AS requested(id)
+		WHERE NOT EXISTS (SELECT 1 FROM tags t WHERE t.id = requested.id)
+		ORDER BY requested.id
+	`
+	rows, err := repository.db.Query(query, pq.Array(tagIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var unknownTagIDs []int64
+	for rows.Next() {
+		var tagID int64
+		err := rows.Scan(&tagID)
+		if err != nil {
+			return err
+		}
+		unknownTagIDs = append(unknownTagIDs, tagID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if featureExists && len(unknownTagIDs) == 0 {
+		return nil
+	}
+
+	unknown := &variables.UnknownReferences{TagIDs: unknownTagIDs}
+	if !featureExists {
+		unknown.FeatureID = featureID
+	}
+	return unknown
+}
+
+func pgErrorCode(err error) string {
+	var pgErr pgx.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// insertActiveVersion deactivates the current version of the banner and stores
+// content with its variants as the new active one, so every change is kept in
+// the history.
+func insertActiveVersion(tx *sql.Tx, id int64, content string, variants []models.BannerVariant) error {
+	_, err := tx.Exec("UPDATE versions SET is_active = FALSE WHERE banner_id = $1 AND is_active = TRUE", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO versions (banner_id, is_active, data, variants) VALUES ($1, TRUE, $2, $3)", id, content, variantsColumn(variants))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// variantsColumn stores banner variants in a nullable JSONB column. A banner
+// without variants keeps NULL there.
+type variantsColumn []models.BannerVariant
+
+func (column *variantsColumn) Scan(src interface{}) error {
+	*column = nil
+	if src == nil {
+		return nil
+	}
+
+	var raw []byte
+	switch value := src.(type) {
+	case []byte:
+		raw = value
+	case string:
+		raw = []byte(value)
+	default:
+		return fmt.Errorf("cannot scan %T into banner variants", src)
+	}
+
+	return json.Unmarshal(raw, (*[]models.BannerVariant)(column))
+}
+
+func (column variantsColumn) Value() (driver.Value, error) {
+	if len(column) == 0 {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal([]models.BannerVariant(column))
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
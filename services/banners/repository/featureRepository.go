@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"database/sql"
+	"encoding/json"
+)
+
+func (repository *BannerRepository) FeatureSchema(featureID int64) (json.RawMessage, error) {
+	var schema []byte
+	err := repository.db.QueryRow("SELECT schema FROM features WHERE id = $1", featureID).Scan(&schema)
+	if err == sql.ErrNoRows {
+		return nil, variables.ErrFeatureNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// SetFeatureSchema attaches schema to the feature. A nil schema removes it.
+func (repository *BannerRepository) SetFeatureSchema(featureID int64, schema json.RawMessage) error {
+	var value any
+	if schema != nil {
+		value = string(schema)
+	}
+
+	result, err := repository.db.Exec("UPDATE features SET schema = $1 WHERE id = $2", value, featureID)
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return variables.ErrFeatureNotFound
+	}
+
+	return nil
+}
+
+func (repository *BannerRepository) GetFeatures(limit, offset int64) ([]models.Feature, error) {
+	rows, err := repository.db.Query("SELECT id, name FROM features ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	features := make([]models.Feature, 0)
+	for rows.Next() {
+		var feature models.Feature
+		err := rows.Scan(&feature.FeatureID, &feature.Name)
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, feature)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return features, nil
+}
+
+func (repository *BannerRepository) AddFeature(name string) (int64, error) {
+	var id int64
+	err := repository.db.QueryRow("INSERT INTO features (name) VALUES ($1) RETURNING id", name).Scan(&id)
+	if pgErrorCode(err) == variables.UniqueViolationCode {
+		return 0, variables.ErrFeatureExists
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository *BannerRepository) RenameFeature(id int64, name string) error {
+	result, err := repository.db.Exec("UPDATE features SET name = $1 WHERE id = $2", name, id)
+	if pgErrorCode(err) == variables.UniqueViolationCode {
+		return variables.ErrFeatureExists
+	}
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return variables.ErrFeatureNotFound
+	}
+
+	return nil
+}
+
+// DeleteFeature removes the feature unless a banner still refers to it.
+func (repository *BannerRepository) DeleteFeature(id int64) error {
+	result, err := repository.db.Exec("DELETE FROM features WHERE id = $1", id)
+	if pgErrorCode(err) == variables.ForeignKeyViolationCode {
+		return variables.ErrFeatureInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return variables.ErrFeatureNotFound
+	}
+
+	return nil
+}
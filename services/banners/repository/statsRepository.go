@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"github.com/lib/pq"
+)
+
+// AddBannerStats adds the counters to the daily totals of their banners.
+// Counters of banners deleted in the meantime are skipped.
+func (repository *BannerRepository) AddBannerStats(stats []models.BannerStats) error {
+	bannerIDs := make([]int64, 0, len(stats))
+	days := make([]string, 0, len(stats))
+	impressions := make([]int64, 0, len(stats))
+	clicks := make([]int64, 0, len(stats))
+	for _, counter := range stats {
+		bannerIDs = append(bannerIDs, counter.BannerID)
+		days = append(days, counter.Day)
+		impressions = append(impressions, counter.Impressions)
+		clicks = append(clicks, counter.Clicks)
+	}
+
+	query := `
+		INSERT INTO banner_stats (banner_id, day, impressions, clicks)
+		SELECT s.banner_id, s.day, s.impressions, s.clicks
+		FROM unnest($1::int[], $2::date[], $3::bigint[], $4::bigint[]) AS s(banner_id, day, impressions, clicks)
+		INNER JOIN banners b ON b.id = s.banner_id
+		ON CONFLICT (banner_id, day) DO UPDATE
+		SET impressions = banner_stats.impressions + EXCLUDED.impressions,
+			clicks = banner_stats.clicks + EXCLUDED.clicks
+	`
+	_, err := repository.db.Exec(query, pq.Array(bannerIDs), pq.Array(days), pq.Array(impressions), pq.Array(clicks))
+	return err
+}
+
+// BannerStats returns the daily counters of the banner between from and to
+// inclusive. Empty bounds leave the range open.
+func (repository *BannerRepository) BannerStats(id int64, from, to string) ([]models.BannerStats, error) {
+	var exists bool
+	err := repository.db.QueryRow("SELECT EXISTS(SELECT 1 FROM banners WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, variables.ErrBannerNotFound
+	}
+
+	var fromValue, toValue any
+	if from != "" {
+		fromValue = from
+	}
+	if to != "" {
+		toValue = to
+	}
+
+	query := `
+		SELECT banner_id, day::text, impressions, clicks
+		FROM banner_stats
+		WHERE banner_id = $1 AND ($2::date IS NULL OR day >= $2::date) AND ($3::date IS NULL OR day <= $3::date)
+		ORDER BY day
+	`
+	rows, err := repository.db.Query(query, id, fromValue, toValue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]models.BannerStats, 0)
+	for rows.Next() {
+		var counter models.BannerStats
+		err := rows.Scan(&counter.BannerID, &counter.Day, &counter.Impressions, &counter.Clicks)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, counter)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
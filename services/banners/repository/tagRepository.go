@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"database/sql"
+)
+
+func (repository *BannerRepository) GetTags(limit, offset int64) ([]models.Tag, error) {
+	rows, err := repository.db.Query("SELECT id, name FROM tags ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]models.Tag, 0)
+	for rows.Next() {
+		var tag models.Tag
+		err := rows.Scan(&tag.TagID, &tag.Name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (repository *BannerRepository) AddTag(name string) (int64, error) {
+	var id int64
+	err := repository.db.QueryRow("INSERT INTO tags (name) VALUES ($1) RETURNING id", name).Scan(&id)
+	if pgErrorCode(err) == variables.UniqueViolationCode {
+		return 0, variables.ErrTagExists
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (repository *BannerRepository) RenameTag(id int64, name string) error {
+	result, err := repository.db.Exec("UPDATE tags SET name = $1 WHERE id = $2", name, id)
+	if pgErrorCode(err) == variables.UniqueViolationCode {
+		return variables.ErrTagExists
+	}
+	if err != nil {
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return variables.ErrTagNotFound
+	}
+
+	return nil
+}
+
+// DeleteTag removes the tag unless a banner still uses it. banner_tag cascades
+// on tag deletion, so the check has to be explicit.
+func (repository *BannerRepository) DeleteTag(id int64) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var tagID int64
+	err = tx.QueryRow("SELECT id FROM tags WHERE id = $1 FOR UPDATE", id).Scan(&tagID)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return variables.ErrTagNotFound
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var inUse bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM banner_tag WHERE tag_id = $1)", id).Scan(&inUse)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if inUse {
+		tx.Rollback()
+		return variables.ErrTagInUse
+	}
+
+	_, err = tx.Exec("DELETE FROM tags WHERE id = $1", id)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
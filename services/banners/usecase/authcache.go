@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"container/list"
+	"sync"
+	"time"
+)
+
+type authCacheEntry struct {
+	key       string
+	identity  models.Identity
+	known     bool
+	expiresAt time.Time
+}
+
+// authCache is a bounded LRU of Authenticate results keyed by the credential
+// hash. Unknown credentials are cached as well, for a shorter time.
+type authCache struct {
+	mutex       sync.Mutex
+	capacity    int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	entries     map[string]*list.Element
+	order       *list.List
+	stats       models.AuthCacheStats
+	now         func() time.Time
+}
+
+func newAuthCache(capacity int, ttl time.Duration, negativeTTL time.Duration) *authCache {
+	return &authCache{
+		capacity:    capacity,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		now:         time.Now,
+	}
+}
+
+// get returns the cached result for the credential. known is false for
+// credentials the authorization service rejected.
+func (cache *authCache) get(key string) (identity models.Identity, known bool, cached bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, found := cache.entries[key]
+	if !found {
+		cache.stats.Misses++
+		return models.Identity{}, false, false
+	}
+
+	entry := element.Value.(*authCacheEntry)
+	if cache.now().After(entry.expiresAt) {
+		cache.remove(element)
+		cache.stats.Misses++
+		return models.Identity{}, false, false
+	}
+
+	cache.order.MoveToFront(element)
+	if !entry.known {
+		cache.stats.NegativeHits++
+		return models.Identity{}, false, true
+	}
+	cache.stats.Hits++
+	return entry.identity, true, true
+}
+
+// set caches an identity. The entry never outlives the session it belongs to.
+func (cache *authCache) set(key string, identity models.Identity) {
+	expiresAt := cache.now().Add(cache.ttl)
+	if identity.ExpiresAt != nil && identity.ExpiresAt.Before(expiresAt) {
+		expiresAt = *identity.ExpiresAt
+	}
+	cache.put(&authCacheEntry{key: key, identity: identity, known: true, expiresAt: expiresAt})
+}
+
+func (cache *authCache) setUnknown(key string) {
+	cache.put(&authCacheEntry{key: key, expiresAt: cache.now().Add(cache.negativeTTL)})
+}
+
+func (cache *authCache) put(entry *authCacheEntry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, found := cache.entries[entry.key]; found {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[entry.key] = cache.order.PushFront(entry)
+	if cache.order.Len() > cache.capacity {
+		cache.remove(cache.order.Back())
+		cache.stats.Evictions++
+	}
+}
+
+func (cache *authCache) invalidate(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, found := cache.entries[key]; found {
+		cache.remove(element)
+		cache.stats.Invalidations++
+	}
+}
+
+// clear drops every entry, for when revocations may have been missed.
+func (cache *authCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.stats.Invalidations += uint64(cache.order.Len())
+	cache.entries = make(map[string]*list.Element)
+	cache.order.Init()
+}
+
+func (cache *authCache) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*authCacheEntry).key)
+}
+
+func (cache *authCache) statistics() models.AuthCacheStats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	stats := cache.stats
+	stats.Size = cache.order.Len()
+	if lookups := stats.Hits + stats.NegativeHits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits+stats.NegativeHits) / float64(lookups)
+	}
+	return stats
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestAuthCacheHitAndMiss(t *testing.T) {
+	cache := newAuthCache(10, time.Minute, time.Minute)
+
+	if _, _, cached := cache.get("a"); cached {
+		t.Fatal("get() on an empty cache reported a cached entry")
+	}
+
+	cache.set("a", models.Identity{UserID: 1, Roles: []string{"user"}})
+	identity, known, cached := cache.get("a")
+	if !cached || !known || identity.UserID != 1 {
+		t.Errorf("get() = %+v, %v, %v, want user 1, true, true", identity, known, cached)
+	}
+
+	stats := cache.statistics()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Size != 1 || stats.HitRate != 0.5 {
+		t.Errorf("statistics() = %+v", stats)
+	}
+}
+
+func newTestAuthCache(ttl time.Duration, negativeTTL time.Duration) (*authCache, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	cache := newAuthCache(10, ttl, negativeTTL)
+	cache.now = clock.Now
+	return cache, clock
+}
+
+func TestAuthCacheExpiry(t *testing.T) {
+	cache, clock := newTestAuthCache(5*time.Second, 2*time.Second)
+	cache.set("known", models.Identity{UserID: 1})
+	cache.setUnknown("unknown")
+
+	clock.Advance(2*time.Second + time.Nanosecond)
+	if _, _, cached := cache.get("unknown"); cached {
+		t.Error("negative entry outlived its TTL")
+	}
+	if _, known, cached := cache.get("known"); !cached || !known {
+		t.Error("entry expired before its TTL")
+	}
+
+	clock.Advance(3 * time.Second)
+	if _, _, cached := cache.get("known"); cached {
+		t.Error("entry outlived its TTL")
+	}
+	if size := cache.statistics().Size; size != 0 {
+		t.Errorf("expired entries kept, size = %d", size)
+	}
+}
+
+func TestAuthCacheSessionExpiry(t *testing.T) {
+	cache, clock := newTestAuthCache(time.Minute, time.Minute)
+
+	expiresAt := clock.Now().Add(time.Second)
+	cache.set("session", models.Identity{UserID: 1, ExpiresAt: &expiresAt})
+
+	if _, _, cached := cache.get("session"); !cached {
+		t.Fatal("entry of a live session is not cached")
+	}
+
+	clock.Advance(time.Second + time.Nanosecond)
+	if _, _, cached := cache.get("session"); cached {
+		t.Error("entry outlived the session")
+	}
+}
+
+func TestAuthCacheNegativeEntries(t *testing.T) {
+	cache := newAuthCache(10, time.Minute, time.Minute)
+	cache.setUnknown("a")
+
+	identity, known, cached := cache.get("a")
+	if !cached || known || identity.UserID != 0 {
+		t.Errorf("get() = %+v, %v, %v, want a cached unknown credential", identity, known, cached)
+	}
+	if stats := cache.statistics(); stats.NegativeHits != 1 || stats.Hits != 0 {
+		t.Errorf("statistics() = %+v, want one negative hit", stats)
+	}
+
+	cache.set("a", models.Identity{UserID: 2})
+	if identity, known, _ := cache.get("a"); !known || identity.UserID != 2 {
+		t.Errorf("get() = %+v, %v after set, want user 2", identity, known)
+	}
+}
+
+func TestAuthCacheEviction(t *testing.T) {
+	cache := newAuthCache(2, time.Minute, time.Minute)
+	cache.set("a", models.Identity{UserID: 1})
+	cache.set("b", models.Identity{UserID: 2})
+
+	// Using a makes b the least recently used entry.
+	cache.get("a")
+	cache.setUnknown("c")
+
+	if _, _, cached := cache.get("b"); cached {
+		t.Error("least recently used entry was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, _, cached := cache.get(key); !cached {
+			t.Errorf("entry %q was evicted", key)
+		}
+	}
+	if stats := cache.statistics(); stats.Evictions != 1 || stats.Size != 2 {
+		t.Errorf("statistics() = %+v, want one eviction and size 2", stats)
+	}
+}
+
+func TestAuthCacheInvalidate(t *testing.T) {
+	cache := newAuthCache(10, time.Minute, time.Minute)
+	cache.set("a", models.Identity{UserID: 1})
+	cache.set("b", models.Identity{UserID: 2})
+	cache.set("c", models.Identity{UserID: 3})
+
+	cache.invalidate("a")
+	cache.invalidate("missing")
+	if _, _, cached := cache.get("a"); cached {
+		t.Error("invalidated entry is still cached")
+	}
+
+	cache.clear()
+	for _, key := range []string{"b", "c"} {
+		if _, _, cached := cache.get(key); cached {
+			t.Errorf("entry %q survived clear()", key)
+		}
+	}
+	if stats := cache.statistics(); stats.Invalidations != 3 || stats.Size != 0 {
+		t.Errorf("statistics() = %+v, want 3 invalidations and size 0", stats)
+	}
+}
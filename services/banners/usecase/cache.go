@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"container/list"
+	"sync"
+	"time"
+)
+
+type bannerCacheKey struct {
+	featureID int64
+	tagID     int64
+}
+
+type bannerCacheEntry struct {
+	key       bannerCacheKey
+	banner    *models.Banner
+	expiresAt time.Time
+}
+
+// bannerCache keeps user banners for the staleness window, so that requests
+// without use_last_revision do not reach the database. It is a bounded LRU;
+// pairs without a banner are kept for a shorter time.
+type bannerCache struct {
+	mutex       sync.Mutex
+	capacity    int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	entries     map[bannerCacheKey]*list.Element
+	order       *list.List
+	now         func() time.Time
+}
+
+func newBannerCache(capacity int, ttl time.Duration, negativeTTL time.Duration) *bannerCache {
+	return &bannerCache{
+		capacity:    capacity,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		entries:     make(map[bannerCacheKey]*list.Element),
+		order:       list.New(),
+		now:         time.Now,
+	}
+}
+
+func (cache *bannerCache) get(featureID int64, tagID int64) (*models.Banner, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, found := cache.entries[bannerCacheKey{featureID: featureID, tagID: tagID}]
+	if !found {
+		return nil, false
+	}
+
+	entry := element.Value.(*bannerCacheEntry)
+	if cache.now().After(entry.expiresAt) {
+		cache.remove(element)
+		return nil, false
+	}
+
+	cache.order.MoveToFront(element)
+	return entry.banner, true
+}
+
+// set stores the banner for the pair. A nil banner is cached for the negative
+// TTL only, so unknown pairs neither hit the database on every request nor
+// hold their slot for long.
+func (cache *bannerCache) set(featureID int64, tagID int64, banner *models.Banner) {
+	ttl := cache.ttl
+	if banner == nil {
+		ttl = cache.negativeTTL
+	}
+	key := bannerCacheKey{featureID: featureID, tagID: tagID}
+	entry := &bannerCacheEntry{key: key, banner: banner, expiresAt: cache.now().Add(ttl)}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, found := cache.entries[key]; found {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(entry)
+	if cache.order.Len() > cache.capacity {
+		cache.remove(cache.order.Back())
+	}
+}
+
+func (cache *bannerCache) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*bannerCacheEntry).key)
+}
+
+func (cache *bannerCache) removeExpired() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	now := cache.now()
+	for element := cache.order.Front(); element != nil; {
+		next := element.Next()
+		if now.After(element.Value.(*bannerCacheEntry).expiresAt) {
+			cache.remove(element)
+		}
+		element = next
+	}
+}
+
+func (cache *bannerCache) runCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cache.removeExpired()
+	}
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *fakeClock) Advance(d time.Duration) {
+	clock.now = clock.now.Add(d)
+}
+
+func newTestBannerCache(capacity int) (*bannerCache, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	cache := newBannerCache(capacity, 5*time.Minute, 10*time.Second)
+	cache.now = clock.Now
+	return cache, clock
+}
+
+func TestBannerCacheExpiry(t *testing.T) {
+	cache, clock := newTestBannerCache(10)
+	banner := &models.Banner{BannerID: 1}
+	cache.set(1, 1, banner)
+	cache.set(1, 2, nil)
+
+	clock.Advance(10*time.Second + time.Nanosecond)
+	if _, found := cache.get(1, 2); found {
+		t.Error("missing banner cached past the negative TTL")
+	}
+	if got, found := cache.get(1, 1); !found || got != banner {
+		t.Errorf("get() = %v, %v, want the cached banner", got, found)
+	}
+
+	clock.Advance(5 * time.Minute)
+	if _, found := cache.get(1, 1); found {
+		t.Error("banner cached past the TTL")
+	}
+}
+
+func TestBannerCacheMissingBanner(t *testing.T) {
+	cache, _ := newTestBannerCache(10)
+	cache.set(1, 1, nil)
+
+	banner, found := cache.get(1, 1)
+	if !found || banner != nil {
+		t.Errorf("get() = %v, %v, want a cached nil banner", banner, found)
+	}
+}
+
+func TestBannerCacheEviction(t *testing.T) {
+	cache, _ := newTestBannerCache(2)
+	cache.set(1, 1, &models.Banner{BannerID: 1})
+	cache.set(1, 2, &models.Banner{BannerID: 2})
+
+	// Using (1, 1) makes (1, 2) the least recently used pair.
+	cache.get(1, 1)
+	cache.set(1, 3, nil)
+
+	if _, found := cache.get(1, 2); found {
+		t.Error("least recently used pair was kept")
+	}
+	for _, tagID := range []int64{1, 3} {
+		if _, found := cache.get(1, tagID); !found {
+			t.Errorf("pair (1, %d) was evicted", tagID)
+		}
+	}
+	if size := cache.order.Len(); size != 2 {
+		t.Errorf("cache holds %d entries, want 2", size)
+	}
+}
+
+func TestBannerCacheRemoveExpired(t *testing.T) {
+	cache, clock := newTestBannerCache(10)
+	cache.set(1, 1, &models.Banner{BannerID: 1})
+	cache.set(1, 2, nil)
+
+	clock.Advance(time.Minute)
+	cache.removeExpired()
+
+	if _, found := cache.entries[bannerCacheKey{featureID: 1, tagID: 2}]; found {
+		t.Error("expired entry kept")
+	}
+	if _, found := cache.entries[bannerCacheKey{featureID: 1, tagID: 1}]; !found {
+		t.Error("live entry removed")
+	}
+}
@@ -1,28 +1,64 @@
 package usecase
 
 import (
+	"avito-track/pkg/jwt"
 	"avito-track/pkg/models"
+	"avito-track/pkg/util"
 	"avito-track/pkg/variables"
 	"avito-track/services/authorization/proto/authorization"
 	"context"
+	"encoding/json"
 	"fmt"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"log/slog"
+	"time"
 )
 
 type IBannerRepository interface {
-	AddBanner(tagIDs []int64, featureID int64, content string) error
-	UpdateBanner(id int64, tagIds []int64, featureID int64, content string) error
+	AddBanner(banner models.Banner) (int64, error)
+	UpdateBanner(id int64, patch models.BannerPatch) error
 	DeleteBanner(id int64) error
-	GetBanners(userRole string, featureID int64, tagIDs []int64, limit, offset int64) ([]models.Banner, error)
-	UserBanner(tagID int64, featureID int64, useLastRevision bool) (*models.Banner, error)
+	GetBanners(filter models.BannerFilter, sort models.BannerSort, limit, offset int64) ([]models.Banner, error)
+	GetBannersAfter(filter models.BannerFilter, after *models.BannerCursor, limit int64) ([]models.Banner, error)
+	CountFilteredBanners(filter models.BannerFilter) (int64, error)
+	UserBanner(tagID int64, featureID int64) (*models.Banner, error)
+	BannerVersions(id int64, limit, offset int64) ([]models.BannerVersion, error)
+	RollbackBanner(id int64, versionID int64, validate func(featureID int64, content json.RawMessage, variants []models.BannerVariant) error) error
+	PruneVersions(keepLast int, maxAgeHours int) (int64, error)
+	CountBanners(featureID int64, tagID int64) (int64, error)
+	DeleteBannersBatch(featureID int64, tagID int64, batchSize int) (int64, error)
+	GetBanner(id int64) (*models.Banner, error)
+	FeatureSchema(featureID int64) (json.RawMessage, error)
+	SetFeatureSchema(featureID int64, schema json.RawMessage) error
+	CheckReferences(featureID int64, tagIDs []int64) error
+	GetFeatures(limit, offset int64) ([]models.Feature, error)
+	AddFeature(name string) (int64, error)
+	RenameFeature(id int64, name string) error
+	DeleteFeature(id int64) error
+	GetTags(limit, offset int64) ([]models.Tag, error)
+	AddTag(name string) (int64, error)
+	RenameTag(id int64, name string) error
+	DeleteTag(id int64) error
+	AddBannerStats(stats []models.BannerStats) error
+	BannerStats(id int64, from, to string) ([]models.BannerStats, error)
+	ImportBanners(banners []models.Banner, dryRun bool) ([]error, error)
+	ExportBanners(write func(models.Banner) error) error
 }
 
 type Core struct {
 	logger            *slog.Logger
 	bannersRepository IBannerRepository
 	grpcClient        authorization.AuthorizationClient
+	bannersCache      *bannerCache
+	deleteJobs        *deleteJobs
+	stats             *statsQueue
+	keys              *verificationKeys
+	authCache         *authCache
+	revoked           *jwt.RevocationList
+	schemas           *schemaCache
 }
 
 func GetGrpcClient(address string) (authorization.AuthorizationClient, error) {
@@ -35,49 +71,230 @@ func GetGrpcClient(address string) (authorization.AuthorizationClient, error) {
 	return client, nil
 }
 
-func GetCore(configGrpc variables.GrpcConfig, banners IBannerRepository, logger *slog.Logger) *Core {
+func GetCore(configGrpc variables.GrpcConfig, configRetention variables.VersionRetentionConfig, banners IBannerRepository, logger *slog.Logger) *Core {
 	client, err := GetGrpcClient(configGrpc.Address + ":" + configGrpc.Port)
 	if err != nil {
 		logger.Error(variables.GrpcConnectError, ": %w", err)
 		return nil
 	}
-	return &Core{
+
+	cache := newBannerCache(variables.BannerCacheSize, variables.BannerCacheTTL, variables.BannerCacheNegativeTTL)
+	go cache.runCleanup(variables.BannerCacheCleanupInterval)
+
+	keys := newVerificationKeys(client, logger)
+	go keys.runRefresh(variables.SigningKeysRefresh)
+
+	core := &Core{
 		bannersRepository: banners,
 		grpcClient:        client,
 		logger:            logger,
+		bannersCache:      cache,
+		deleteJobs:        newDeleteJobs(),
+		stats:             newStatsQueue(variables.StatsBufferSize),
+		keys:              keys,
+		authCache:         newAuthCache(variables.AuthCacheSize, variables.AuthCacheTTL, variables.AuthCacheNegativeTTL),
+		revoked:           jwt.NewRevocationList(),
+		schemas:           newSchemaCache(),
 	}
+
+	go core.runStatsAggregator(variables.StatsFlushInterval)
+	go core.runRevocationsWatcher(variables.RevocationsReconnectPeriod)
+
+	if configRetention.KeepLast > 0 || configRetention.MaxAgeHours > 0 {
+		go core.runVersionsPruner(configRetention)
+	}
+
+	return core
 }
 
-func (core *Core) UserBanner(tagID int64, featureID int64, useLastRevision bool) (*models.Banner, error) {
-	banner, err := core.bannersRepository.UserBanner(tagID, featureID, useLastRevision)
+func (core *Core) runVersionsPruner(config variables.VersionRetentionConfig) {
+	timer := config.Timer
+	if timer == 0 {
+		timer = variables.DefaultRetentionTimer
+	}
+
+	ticker := time.NewTicker(time.Duration(timer) * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		pruned, err := core.bannersRepository.PruneVersions(config.KeepLast, config.MaxAgeHours)
+		if err != nil {
+			core.logger.Error(variables.PruneVersionsError, ": %w", err)
+			continue
+		}
+		core.logger.Info(variables.VersionsPrunedMessage, "count", pruned)
+	}
+}
+
+// UserBanner returns the live banner for the pair. When the banner has variants,
+// the content of the one assigned to userID is served instead. The schedule is
+// checked on every call, cached banners included.
+func (core *Core) UserBanner(tagID int64, featureID int64, userID int64, useLastRevision bool) (*models.Banner, error) {
+	var banner *models.Banner
+	found := false
+	if !useLastRevision {
+		banner, found = core.bannersCache.get(featureID, tagID)
+	}
+
+	if !found {
+		var err error
+		banner, err = core.bannersRepository.UserBanner(tagID, featureID)
+		if err != nil {
+			core.logger.Error(variables.BannerNotFoundError, ": %w", err)
+			return nil, err
+		}
+		core.bannersCache.set(featureID, tagID, banner)
+	}
+
+	if banner == nil || util.BannerStatus(banner.StartsAt, banner.EndsAt, time.Now()) != variables.BannerStatusLive {
+		return nil, nil
+	}
+	return pickVariant(banner, userID), nil
+}
+
+// GetBanners lists banners matching the filter. Admins see every banner, while
+// users only ever see active banners that are live right now, whatever status
+// they ask for.
+func (core *Core) GetBanners(userRole string, filter models.BannerFilter, sort models.BannerSort, limit, offset int64) ([]models.Banner, error) {
+	banners, err := core.bannersRepository.GetBanners(roleFilter(userRole, filter), sort, limit, offset)
 	if err != nil {
 		core.logger.Error(variables.BannerNotFoundError, ": %w", err)
 		return nil, err
 	}
-	return banner, nil
+
+	setBannerStatuses(banners)
+	return banners, nil
 }
 
-func (core *Core) GetBanners(userRole string, featureID int64, tagIDs []int64, limit, offset int64) ([]models.Banner, error) {
-	banners, err := core.bannersRepository.GetBanners(userRole, featureID, tagIDs, limit, offset)
+// GetBannersPage lists banners like GetBanners, newest first, starting right
+// after the cursor. The page points to the next one unless it is the last, and
+// carries the number of matching banners when withTotal is set.
+func (core *Core) GetBannersPage(userRole string, filter models.BannerFilter, after *models.BannerCursor, limit int64, withTotal bool) (models.BannerPage, error) {
+	filter = roleFilter(userRole, filter)
+
+	banners, err := core.bannersRepository.GetBannersAfter(filter, after, limit+1)
 	if err != nil {
 		core.logger.Error(variables.BannerNotFoundError, ": %w", err)
-		return nil, err
+		return models.BannerPage{}, err
 	}
 
-	return banners, nil
+	var page models.BannerPage
+	if int64(len(banners)) > limit {
+		banners = banners[:limit]
+		last := banners[len(banners)-1]
+
+		updatedAt, err := time.Parse(time.RFC3339Nano, last.UpdatedAt)
+		if err != nil {
+			core.logger.Error(variables.BannerCursorError, ": %w", err)
+			return models.BannerPage{}, err
+		}
+		page.Next = &models.BannerCursor{UpdatedAt: updatedAt, BannerID: last.BannerID}
+	}
+
+	if withTotal {
+		total, err := core.bannersRepository.CountFilteredBanners(filter)
+		if err != nil {
+			core.logger.Error(variables.CountBannersError, ": %w", err)
+			return models.BannerPage{}, err
+		}
+		page.Total = &total
+	}
+
+	setBannerStatuses(banners)
+	page.Banners = banners
+	return page, nil
+}
+
+func roleFilter(userRole string, filter models.BannerFilter) models.BannerFilter {
+	if userRole != variables.AdminRole[0] {
+		filter.ActiveOnly = true
+		filter.Status = variables.BannerStatusLive
+	}
+	return filter
 }
 
-func (core *Core) AddBanner(tagIDs []int64, featureID int64, content string) error {
-	err := core.bannersRepository.AddBanner(tagIDs, featureID, content)
+func setBannerStatuses(banners []models.Banner) {
+	now := time.Now()
+	for i := range banners {
+		banners[i].Status = util.BannerStatus(banners[i].StartsAt, banners[i].EndsAt, now)
+	}
+}
+
+func (core *Core) AddBanner(banner models.Banner) (int64, error) {
+	err := core.bannersRepository.CheckReferences(banner.FeatureID, banner.TagIDs)
+	if err != nil {
+		core.logger.Error(variables.CannotCreateBanner, ": %w", err)
+		return 0, err
+	}
+
+	err = core.validateContent(banner.FeatureID, banner.Content)
+	if err != nil {
+		core.logger.Error(variables.CannotCreateBanner, ": %w", err)
+		return 0, err
+	}
+
+	err = core.validateVariants(banner.FeatureID, banner.Variants)
+	if err != nil {
+		core.logger.Error(variables.CannotCreateBanner, ": %w", err)
+		return 0, err
+	}
+
+	id, err := core.bannersRepository.AddBanner(banner)
 	if err != nil {
 		core.logger.Error(variables.CannotCreateBanner, err)
-		return err
+		return 0, err
 	}
-	return nil
+	return id, nil
 }
 
-func (core *Core) UpdateBanner(id int64, tagIds []int64, featureID int64, content string) error {
-	err := core.bannersRepository.UpdateBanner(id, tagIds, featureID, content)
+func (core *Core) UpdateBanner(id int64, patch models.BannerPatch) error {
+	if patch.Content != nil || patch.Variants != nil || patch.FeatureID != nil || patch.TagIDs != nil {
+		banner, err := core.bannersRepository.GetBanner(id)
+		if err != nil {
+			core.logger.Error(variables.BannerNotFoundError, ": %w", err)
+			return err
+		}
+
+		featureID := banner.FeatureID
+		if patch.FeatureID != nil {
+			featureID = *patch.FeatureID
+		}
+
+		var tagIDs []int64
+		if patch.TagIDs != nil {
+			tagIDs = *patch.TagIDs
+		}
+
+		err = core.bannersRepository.CheckReferences(featureID, tagIDs)
+		if err != nil {
+			core.logger.Error(variables.UnknownReferencesError, ": %w", err)
+			return err
+		}
+
+		content := banner.Content
+		if patch.Content != nil {
+			content = *patch.Content
+		}
+
+		err = core.validateContent(featureID, content)
+		if err != nil {
+			core.logger.Error(variables.ContentValidationError, ": %w", err)
+			return err
+		}
+
+		variants := banner.Variants
+		if patch.Variants != nil {
+			variants = *patch.Variants
+		}
+
+		err = core.validateVariants(featureID, variants)
+		if err != nil {
+			core.logger.Error(variables.ContentValidationError, ": %w", err)
+			return err
+		}
+	}
+
+	err := core.bannersRepository.UpdateBanner(id, patch)
 	if err != nil {
 		core.logger.Error(variables.BannerNotFoundError, ": %w", err)
 		return err
@@ -94,6 +311,158 @@ func (core *Core) DeleteBanner(id int64) error {
 	return nil
 }
 
+// DeleteBanners starts a background job that deletes every banner matching the
+// filters in batches and returns its initial state.
+func (core *Core) DeleteBanners(featureID int64, tagID int64) (models.DeleteJob, error) {
+	total, err := core.bannersRepository.CountBanners(featureID, tagID)
+	if err != nil {
+		core.logger.Error(variables.CountBannersError, ": %w", err)
+		return models.DeleteJob{}, err
+	}
+
+	job := core.deleteJobs.create(featureID, tagID, total)
+	go core.runDeleteJob(job.JobID, featureID, tagID)
+
+	return job, nil
+}
+
+func (core *Core) runDeleteJob(id int64, featureID int64, tagID int64) {
+	core.deleteJobs.update(id, func(job *models.DeleteJob) {
+		job.Status = variables.DeleteJobStatusRunning
+	})
+
+	for {
+		deleted, err := core.bannersRepository.DeleteBannersBatch(featureID, tagID, variables.DeleteBatchSize)
+		if err != nil {
+			core.logger.Error(variables.DeleteBannersBatchError, ": %w", err)
+			core.deleteJobs.update(id, func(job *models.DeleteJob) {
+				job.Status = variables.DeleteJobStatusFailed
+				job.Error = err.Error()
+			})
+			return
+		}
+
+		if deleted == 0 {
+			// The batch skips locked rows, so it may find nothing while
+			// matching banners are still being updated.
+			remaining, err := core.bannersRepository.CountBanners(featureID, tagID)
+			if err != nil {
+				core.logger.Error(variables.CountBannersError, ": %w", err)
+				core.deleteJobs.update(id, func(job *models.DeleteJob) {
+					job.Status = variables.DeleteJobStatusFailed
+					job.Error = err.Error()
+				})
+				return
+			}
+			if remaining > 0 {
+				time.Sleep(variables.DeleteBatchPause)
+				continue
+			}
+
+			core.deleteJobs.update(id, func(job *models.DeleteJob) {
+				job.Status = variables.DeleteJobStatusDone
+			})
+			return
+		}
+
+		core.deleteJobs.update(id, func(job *models.DeleteJob) {
+			job.Deleted += deleted
+		})
+		time.Sleep(variables.DeleteBatchPause)
+	}
+}
+
+func (core *Core) DeleteJob(id int64) (models.DeleteJob, error) {
+	job, found := core.deleteJobs.get(id)
+	if !found {
+		return models.DeleteJob{}, variables.ErrDeleteJobNotFound
+	}
+	return job, nil
+}
+
+func (core *Core) BannerVersions(id int64, limit, offset int64) ([]models.BannerVersion, error) {
+	versions, err := core.bannersRepository.BannerVersions(id, limit, offset)
+	if err != nil {
+		core.logger.Error(variables.BannerVersionsError, ": %w", err)
+		return nil, err
+	}
+	return versions, nil
+}
+
+// RollbackBanner activates an earlier version once its content passes the
+// current schema of the feature.
+func (core *Core) RollbackBanner(id int64, versionID int64) error {
+	err := core.bannersRepository.RollbackBanner(id, versionID, func(featureID int64, content json.RawMessage, variants []models.BannerVariant) error {
+		err := core.validateContent(featureID, content)
+		if err != nil {
+			return err
+		}
+		return core.validateVariants(featureID, variants)
+	})
+	if err != nil {
+		core.logger.Error(variables.BannerRollbackError, ": %w", err)
+		return err
+	}
+	return nil
+}
+
+func (core *Core) FeatureSchema(featureID int64) (json.RawMessage, error) {
+	schema, err := core.bannersRepository.FeatureSchema(featureID)
+	if err != nil {
+		core.logger.Error(variables.FeatureSchemaError, ": %w", err)
+		return nil, err
+	}
+	return schema, nil
+}
+
+// SetFeatureSchema attaches a JSON Schema to the feature after making sure it
+// compiles. A nil schema removes the validation.
+func (core *Core) SetFeatureSchema(featureID int64, schema json.RawMessage) error {
+	if schema != nil {
+		_, err := compileFeatureSchema(featureID, schema)
+		if err != nil {
+			core.logger.Error(variables.InvalidSchemaError, ": %w", err)
+			return err
+		}
+	}
+
+	err := core.bannersRepository.SetFeatureSchema(featureID, schema)
+	if err != nil {
+		core.logger.Error(variables.FeatureSchemaError, ": %w", err)
+		return err
+	}
+	return nil
+}
+
+// RecordImpression counts a banner shown to a user. It never blocks.
+func (core *Core) RecordImpression(bannerID int64) {
+	core.stats.push(bannerID, false)
+}
+
+// RecordClick counts a click on a banner. The banner must be the one the user
+// is shown for the tag and feature right now. Counting never blocks.
+func (core *Core) RecordClick(tagID int64, featureID int64, userID int64, bannerID int64) error {
+	banner, err := core.UserBanner(tagID, featureID, userID, false)
+	if err != nil {
+		return err
+	}
+	if banner == nil || banner.BannerID != bannerID {
+		return variables.ErrBannerNotFound
+	}
+
+	core.stats.push(bannerID, true)
+	return nil
+}
+
+func (core *Core) BannerStats(id int64, from, to string) ([]models.BannerStats, error) {
+	stats, err := core.bannersRepository.BannerStats(id, from, to)
+	if err != nil {
+		core.logger.Error(variables.BannerStatsError, ": %w", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
 func (core *Core) GetUserRole(ctx context.Context, id int64) (string, error) {
 	grpcRequest := authorization.RoleRequest{Id: id}
 
@@ -105,13 +474,84 @@ func (core *Core) GetUserRole(ctx context.Context, id int64) (string, error) {
 	return grpcResponse.GetRole(), nil
 }
 
-func (core *Core) GetUserId(ctx context.Context, sid string) (int64, error) {
-	grpcRequest := authorization.FindIdRequest{Sid: sid}
+// VerifyAccessToken checks an access token issued by the authorization service
+// with its published keys. Tokens of ended sessions and API tokens are rejected
+// once the revocation has been received.
+func (core *Core) VerifyAccessToken(token string) (jwt.Claims, error) {
+	claims, err := jwt.Parse(token, core.keys.publicKey)
+	if err != nil {
+		return jwt.Claims{}, err
+	}
+
+	if core.revoked.Contains(claims.CredentialID) {
+		return jwt.Claims{}, variables.ErrRevokedJWT
+	}
+	return claims, nil
+}
+
+// Authenticate resolves the credential with a single call to the
+// authorization service. Results, rejections included, are cached for a few
+// seconds.
+func (core *Core) Authenticate(ctx context.Context, sid string) (models.Identity, error) {
+	key := string(util.HashToken(sid))
+	identity, known, cached := core.authCache.get(key)
+	if cached {
+		if !known {
+			return models.Identity{}, variables.ErrSessionNotFound
+		}
+		return identity, nil
+	}
+
+	grpcRequest := authorization.AuthenticateRequest{Sid: sid}
 
-	grpcResponse, err := core.grpcClient.GetId(ctx, &grpcRequest)
+	grpcResponse, err := core.grpcClient.Authenticate(ctx, &grpcRequest)
+	if status.Code(err) == codes.Unauthenticated {
+		core.authCache.setUnknown(key)
+		return models.Identity{}, variables.ErrSessionNotFound
+	}
 	if err != nil {
 		core.logger.Error(variables.GrpcRecievError, err)
-		return 0, fmt.Errorf(variables.GrpcRecievError, err)
+		return models.Identity{}, fmt.Errorf(variables.GrpcRecievError, err)
+	}
+
+	identity = models.Identity{
+		UserID: grpcResponse.GetId(),
+		Login:  grpcResponse.GetLogin(),
+		Roles:  grpcResponse.GetRoles(),
+		Scopes: grpcResponse.GetScopes(),
+	}
+	if grpcResponse.GetExpiresAt() != 0 {
+		expiresAt := time.Unix(grpcResponse.GetExpiresAt(), 0)
+		identity.ExpiresAt = &expiresAt
+	}
+
+	core.authCache.set(key, identity)
+	return identity, nil
+}
+
+func (core *Core) AuthCacheStats() models.AuthCacheStats {
+	return core.authCache.statistics()
+}
+
+// runRevocationsWatcher drops cached credentials as soon as the authorization
+// service ends them. Revocations can be missed while the stream is down, so the
+// whole cache is dropped before reconnecting.
+func (core *Core) runRevocationsWatcher(reconnectPeriod time.Duration) {
+	for {
+		stream, err := core.grpcClient.WatchRevocations(context.Background(), &authorization.RevocationsRequest{})
+		for err == nil {
+			var revocation *authorization.Revocation
+			revocation, err = stream.Recv()
+			if err == nil {
+				core.authCache.invalidate(string(revocation.GetCredentialHash()))
+				if revocation.GetAccessTokensUntil() != 0 {
+					core.revoked.Add(jwt.CredentialID(revocation.GetCredentialHash()), time.Unix(revocation.GetAccessTokensUntil(), 0))
+				}
+			}
+		}
+
+		core.logger.Error(variables.WatchRevocationsError, "error", err.Error())
+		core.authCache.clear()
+		time.Sleep(reconnectPeriod)
 	}
-	return grpcResponse.Value, nil
 }
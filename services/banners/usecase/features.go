@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+)
+
+func (core *Core) GetFeatures(limit, offset int64) ([]models.Feature, error) {
+	features, err := core.bannersRepository.GetFeatures(limit, offset)
+	if err != nil {
+		core.logger.Error(variables.FeatureRequestError, ": %w", err)
+		return nil, err
+	}
+	return features, nil
+}
+
+func (core *Core) AddFeature(name string) (int64, error) {
+	id, err := core.bannersRepository.AddFeature(name)
+	if err != nil {
+		core.logger.Error(variables.FeatureRequestError, ": %w", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (core *Core) RenameFeature(id int64, name string) error {
+	err := core.bannersRepository.RenameFeature(id, name)
+	if err != nil {
+		core.logger.Error(variables.FeatureRequestError, ": %w", err)
+		return err
+	}
+	return nil
+}
+
+func (core *Core) DeleteFeature(id int64) error {
+	err := core.bannersRepository.DeleteFeature(id)
+	if err != nil {
+		core.logger.Error(variables.FeatureRequestError, ": %w", err)
+		return err
+	}
+	return nil
+}
+
+func (core *Core) GetTags(limit, offset int64) ([]models.Tag, error) {
+	tags, err := core.bannersRepository.GetTags(limit, offset)
+	if err != nil {
+		core.logger.Error(variables.TagRequestError, ": %w", err)
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (core *Core) AddTag(name string) (int64, error) {
+	id, err := core.bannersRepository.AddTag(name)
+	if err != nil {
+		core.logger.Error(variables.TagRequestError, ": %w", err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (core *Core) RenameTag(id int64, name string) error {
+	err := core.bannersRepository.RenameTag(id, name)
+	if err != nil {
+		core.logger.Error(variables.TagRequestError, ": %w", err)
+		return err
+	}
+	return nil
+}
+
+func (core *Core) DeleteTag(id int64) error {
+	err := core.bannersRepository.DeleteTag(id)
+	if err != nil {
+		core.logger.Error(variables.TagRequestError, ": %w", err)
+		return err
+	}
+	return nil
+}
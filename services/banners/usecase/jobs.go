@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"sync"
+	"time"
+)
+
+type deleteJobEntry struct {
+	job        models.DeleteJob
+	finishedAt time.Time
+}
+
+// deleteJobs tracks the progress of background bulk deletions. Finished jobs
+// are kept for DeleteJobTTL so that clients can poll the final status. Jobs
+// live in memory only and are lost when the service restarts.
+type deleteJobs struct {
+	mutex   sync.RWMutex
+	lastID  int64
+	entries map[int64]*deleteJobEntry
+}
+
+func newDeleteJobs() *deleteJobs {
+	return &deleteJobs{
+		entries: make(map[int64]*deleteJobEntry),
+	}
+}
+
+func (jobs *deleteJobs) create(featureID int64, tagID int64, total int64) models.DeleteJob {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+
+	now := time.Now()
+	for id, entry := range jobs.entries {
+		if !entry.finishedAt.IsZero() && now.Sub(entry.finishedAt) > variables.DeleteJobTTL {
+			delete(jobs.entries, id)
+		}
+	}
+
+	jobs.lastID++
+	entry := &deleteJobEntry{
+		job: models.DeleteJob{
+			JobID:     jobs.lastID,
+			FeatureID: featureID,
+			TagID:     tagID,
+			Status:    variables.DeleteJobStatusPending,
+			Total:     total,
+		},
+	}
+	jobs.entries[entry.job.JobID] = entry
+
+	return entry.job
+}
+
+func (jobs *deleteJobs) get(id int64) (models.DeleteJob, bool) {
+	jobs.mutex.RLock()
+	defer jobs.mutex.RUnlock()
+
+	entry, found := jobs.entries[id]
+	if !found {
+		return models.DeleteJob{}, false
+	}
+
+	return entry.job, true
+}
+
+func (jobs *deleteJobs) update(id int64, apply func(job *models.DeleteJob)) {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+
+	entry, found := jobs.entries[id]
+	if !found {
+		return
+	}
+
+	apply(&entry.job)
+
+	// Total is counted when the job starts. Banners created or deleted by
+	// others meanwhile change what the batches find, so the total follows
+	// what was actually deleted.
+	if entry.job.Deleted > entry.job.Total || entry.job.Status == variables.DeleteJobStatusDone {
+		entry.job.Total = entry.job.Deleted
+	}
+
+	if entry.job.Status == variables.DeleteJobStatusDone || entry.job.Status == variables.DeleteJobStatusFailed {
+		entry.finishedAt = time.Now()
+	}
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"testing"
+)
+
+func TestDeleteJobsTotal(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int64
+		batches   []int64
+		wantTotal int64
+	}{
+		{name: "as counted", total: 5, batches: []int64{3, 2}, wantTotal: 5},
+		{name: "more matched", total: 2, batches: []int64{2, 3}, wantTotal: 5},
+		{name: "deleted by others", total: 5, batches: []int64{3}, wantTotal: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			jobs := newDeleteJobs()
+			job := jobs.create(1, 0, test.total)
+
+			for _, deleted := range test.batches {
+				jobs.update(job.JobID, func(job *models.DeleteJob) {
+					job.Deleted += deleted
+				})
+				if got, _ := jobs.get(job.JobID); got.Deleted > got.Total {
+					t.Errorf("deleted %d of %d", got.Deleted, got.Total)
+				}
+			}
+
+			jobs.update(job.JobID, func(job *models.DeleteJob) {
+				job.Status = variables.DeleteJobStatusDone
+			})
+			got, _ := jobs.get(job.JobID)
+			if got.Total != test.wantTotal || got.Deleted != test.wantTotal {
+				t.Errorf("finished job = %+v, want %d deleted of %d", got, test.wantTotal, test.wantTotal)
+			}
+		})
+	}
+}
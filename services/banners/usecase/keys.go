@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"avito-track/services/authorization/proto/authorization"
+	"context"
+	"crypto/ed25519"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// verificationKeys caches the public keys of the authorization service, so
+// access tokens are verified without calling it. A token signed with an
+// unknown key triggers a refresh, which picks up rotated keys right away.
+type verificationKeys struct {
+	client      authorization.AuthorizationClient
+	logger      *slog.Logger
+	mutex       sync.RWMutex
+	keys        map[string]models.VerificationKey
+	refreshing  sync.Mutex
+	attemptedAt time.Time
+}
+
+func newVerificationKeys(client authorization.AuthorizationClient, logger *slog.Logger) *verificationKeys {
+	return &verificationKeys{
+		client: client,
+		logger: logger,
+		keys:   make(map[string]models.VerificationKey),
+	}
+}
+
+// refresh fetches the keys, giving up after SigningKeysTimeout so requests
+// waiting for it are not held by a hanging authorization service.
+func (keys *verificationKeys) refresh(ctx context.Context) error {
+	keys.refreshing.Lock()
+	defer keys.refreshing.Unlock()
+
+	// Failed attempts count as well, so an unreachable authorization service
+	// is not called for every token.
+	if time.Since(keys.attemptedAt) < variables.SigningKeysMinRefresh {
+		return nil
+	}
+	keys.attemptedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, variables.SigningKeysTimeout)
+	defer cancel()
+
+	response, err := keys.client.GetKeys(ctx, &authorization.KeysRequest{})
+	if err != nil {
+		return err
+	}
+
+	fetched := make(map[string]models.VerificationKey, len(response.GetKeys()))
+	for _, key := range response.GetKeys() {
+		var expiresAt time.Time
+		if key.GetExpiresAt() != 0 {
+			expiresAt = time.Unix(key.GetExpiresAt(), 0)
+		}
+		fetched[key.GetId()] = models.VerificationKey{KeyID: key.GetId(), PublicKey: key.GetPublicKey(), ExpiresAt: expiresAt}
+	}
+
+	keys.mutex.Lock()
+	keys.keys = fetched
+	keys.mutex.Unlock()
+
+	return nil
+}
+
+func (keys *verificationKeys) runRefresh(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		err := keys.refresh(context.Background())
+		if err != nil {
+			keys.logger.Error(variables.SigningKeysError, "error", err.Error())
+		}
+	}
+}
+
+func (keys *verificationKeys) get(keyID string) (models.VerificationKey, bool) {
+	keys.mutex.RLock()
+	key, found := keys.keys[keyID]
+	keys.mutex.RUnlock()
+
+	return key, found
+}
+
+// publicKey returns the key with the given id, refreshing the keys at most
+// once per SigningKeysMinRefresh when it is not known yet.
+func (keys *verificationKeys) publicKey(keyID string) (ed25519.PublicKey, error) {
+	key, found := keys.get(keyID)
+	if !found {
+		err := keys.refresh(context.Background())
+		if err != nil {
+			keys.logger.Error(variables.SigningKeysError, "error", err.Error())
+			return nil, variables.ErrUnknownJWTKey
+		}
+		key, found = keys.get(keyID)
+	}
+
+	if !found || (!key.ExpiresAt.IsZero() && time.Now().After(key.ExpiresAt)) {
+		return nil, variables.ErrUnknownJWTKey
+	}
+
+	return key.PublicKey, nil
+}
@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"sync/atomic"
+	"time"
+)
+
+type statsKey struct {
+	bannerID int64
+	day      string
+}
+
+type statsEvent struct {
+	statsKey
+	click bool
+}
+
+// statsQueue buffers impressions and clicks on their way to the aggregator, so
+// that recording an event never waits for the database.
+type statsQueue struct {
+	events  chan statsEvent
+	dropped atomic.Int64
+}
+
+func newStatsQueue(size int) *statsQueue {
+	return &statsQueue{
+		events: make(chan statsEvent, size),
+	}
+}
+
+// push queues the event. When the buffer is full the event is dropped and
+// counted instead of blocking the caller.
+func (queue *statsQueue) push(bannerID int64, click bool) {
+	event := statsEvent{
+		statsKey: statsKey{bannerID: bannerID, day: time.Now().UTC().Format(variables.StatsDateLayout)},
+		click:    click,
+	}
+
+	select {
+	case queue.events <- event:
+	default:
+		queue.dropped.Add(1)
+	}
+}
+
+// runStatsAggregator sums queued events into per-banner, per-day counters and
+// writes them out every interval. Counters that fail to be written are kept
+// and retried with the next flush.
+func (core *Core) runStatsAggregator(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	counters := make(map[statsKey]*models.BannerStats)
+	for {
+		select {
+		case event := <-core.stats.events:
+			counter, found := counters[event.statsKey]
+			if !found {
+				counter = &models.BannerStats{BannerID: event.bannerID, Day: event.day}
+				counters[event.statsKey] = counter
+			}
+			if event.click {
+				counter.Clicks++
+			} else {
+				counter.Impressions++
+			}
+		case <-ticker.C:
+			dropped := core.stats.dropped.Swap(0)
+			if dropped > 0 {
+				core.logger.Warn(variables.StatsEventsDroppedMessage, "count", dropped)
+			}
+			if len(counters) == 0 {
+				continue
+			}
+
+			stats := make([]models.BannerStats, 0, len(counters))
+			for _, counter := range counters {
+				stats = append(stats, *counter)
+			}
+
+			err := core.bannersRepository.AddBannerStats(stats)
+			if err != nil {
+				core.logger.Error(variables.FlushStatsError, ": %w", err)
+				continue
+			}
+			counters = make(map[statsKey]*models.BannerStats)
+		}
+	}
+}
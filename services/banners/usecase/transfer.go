@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"errors"
+	"sort"
+)
+
+func (core *Core) ExportBanners(write func(models.Banner) error) error {
+	err := core.bannersRepository.ExportBanners(write)
+	if err != nil {
+		core.logger.Error(variables.ExportBannersError, ": %w", err)
+		return err
+	}
+	return nil
+}
+
+// ImportBanners checks every banner the same way AddBanner does and stores them
+// all in one transaction. Nothing is stored when a row fails or when dryRun is
+// set; the result lists the errors of every failed row.
+func (core *Core) ImportBanners(banners []models.Banner, dryRun bool) (models.ImportResult, error) {
+	result := models.ImportResult{
+		DryRun: dryRun,
+		Errors: make([]models.ImportError, 0),
+	}
+
+	valid := make([]models.Banner, 0, len(banners))
+	rows := make([]int, 0, len(banners))
+	for i, banner := range banners {
+		err := core.bannersRepository.CheckReferences(banner.FeatureID, banner.TagIDs)
+		if err == nil {
+			err = core.validateContent(banner.FeatureID, banner.Content)
+		}
+		if err == nil {
+			err = core.validateVariants(banner.FeatureID, banner.Variants)
+		}
+
+		if err != nil {
+			rowError, ok := importError(i+1, err)
+			if !ok {
+				core.logger.Error(variables.ImportBannersError, ": %w", err)
+				return models.ImportResult{}, err
+			}
+			result.Errors = append(result.Errors, rowError)
+			continue
+		}
+
+		valid = append(valid, banner)
+		rows = append(rows, i+1)
+	}
+
+	rowErrors, err := core.bannersRepository.ImportBanners(valid, dryRun || len(result.Errors) > 0)
+	if err != nil {
+		core.logger.Error(variables.ImportBannersError, ": %w", err)
+		return models.ImportResult{}, err
+	}
+
+	for i, err := range rowErrors {
+		if err == nil {
+			continue
+		}
+		rowError, _ := importError(rows[i], err)
+		result.Errors = append(result.Errors, rowError)
+	}
+
+	if len(result.Errors) > 0 {
+		sort.SliceStable(result.Errors, func(i, j int) bool {
+			return result.Errors[i].Row < result.Errors[j].Row
+		})
+		return result, nil
+	}
+
+	if !dryRun {
+		result.Imported = len(banners)
+	}
+	return result, nil
+}
+
+// importError turns a rejected row into its report. It returns false for
+// errors that are not caused by the row itself.
+func importError(row int, err error) (models.ImportError, bool) {
+	var conflict *variables.BannerConflict
+	if errors.As(err, &conflict) {
+		return models.ImportError{Row: row, Message: variables.BannerConflictError, BannerIDs: conflict.BannerIDs}, true
+	}
+
+	var validation *variables.ContentValidation
+	if errors.As(err, &validation) {
+		return models.ImportError{Row: row, Message: variables.ContentValidationError, Fields: validation.Fields}, true
+	}
+
+	var unknown *variables.UnknownReferences
+	if errors.As(err, &unknown) {
+		return models.ImportError{Row: row, Message: variables.UnknownReferencesError, FeatureID: unknown.FeatureID, TagIDs: unknown.TagIDs}, true
+	}
+
+	if errors.Is(err, variables.ErrInvalidSchedule) {
+		return models.ImportError{Row: row, Message: variables.ScheduleError}, true
+	}
+
+	return models.ImportError{Row: row, Message: err.Error()}, false
+}
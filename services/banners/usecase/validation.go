@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"avito-track/pkg/variables"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var errExternalRef = errors.New("only references inside the schema are allowed")
+
+// compileFeatureSchema compiles the schema on its own. References to other
+// documents are rejected, so a schema can not read files or URLs the service
+// has access to.
+func compileFeatureSchema(featureID int64, schema json.RawMessage) (*jsonschema.Schema, error) {
+	url := "urn:feature:" + strconv.FormatInt(featureID, 10)
+
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(string) (io.ReadCloser, error) {
+		return nil, errExternalRef
+	}
+
+	err := compiler.AddResource(url, bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", variables.ErrInvalidSchema, err)
+	}
+
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", variables.ErrInvalidSchema, err)
+	}
+	return compiled, nil
+}
+
+type compiledSchema struct {
+	source string
+	schema *jsonschema.Schema
+}
+
+// schemaCache keeps the compiled schema of every feature until its source
+// changes.
+type schemaCache struct {
+	mutex   sync.Mutex
+	entries map[int64]compiledSchema
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{entries: make(map[int64]compiledSchema)}
+}
+
+func (cache *schemaCache) get(featureID int64, source json.RawMessage) (*jsonschema.Schema, error) {
+	cache.mutex.Lock()
+	entry, found := cache.entries[featureID]
+	cache.mutex.Unlock()
+
+	if found && entry.source == string(source) {
+		return entry.schema, nil
+	}
+
+	compiled, err := compileFeatureSchema(featureID, source)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mutex.Lock()
+	cache.entries[featureID] = compiledSchema{source: string(source), schema: compiled}
+	cache.mutex.Unlock()
+
+	return compiled, nil
+}
+
+// validateContent checks content against the JSON Schema of the feature and
+// returns a ContentValidation error listing every failed field.
+func (core *Core) validateContent(featureID int64, content json.RawMessage) error {
+	schema, err := core.bannersRepository.FeatureSchema(featureID)
+	if errors.Is(err, variables.ErrFeatureNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		return nil
+	}
+
+	compiled, err := core.schemas.get(featureID, schema)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var value any
+	err = decoder.Decode(&value)
+	if err != nil {
+		return err
+	}
+
+	err = compiled.Validate(value)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return &variables.ContentValidation{Fields: fieldErrors(validationErr, nil)}
+	}
+
+	return err
+}
+
+// validateVariants checks the content of every variant against the feature
+// schema. Failed fields are reported relative to the variants array.
+func (core *Core) validateVariants(featureID int64, variants []models.BannerVariant) error {
+	for i, variant := range variants {
+		err := core.validateContent(featureID, variant.Content)
+		var validation *variables.ContentValidation
+		if errors.As(err, &validation) {
+			prefix := "/variants/" + strconv.Itoa(i) + "/content"
+			for j := range validation.Fields {
+				if validation.Fields[j].Field == "/" {
+					validation.Fields[j].Field = prefix
+				} else {
+					validation.Fields[j].Field = prefix + validation.Fields[j].Field
+				}
+			}
+			return validation
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fieldErrors(validationErr *jsonschema.ValidationError, fields []models.FieldError) []models.FieldError {
+	if len(validationErr.Causes) == 0 {
+		field := validationErr.InstanceLocation
+		if field == "" {
+			field = "/"
+		}
+		return append(fields, models.FieldError{Field: field, Message: validationErr.Message})
+	}
+
+	for _, cause := range validationErr.Causes {
+		fields = fieldErrors(cause, fields)
+	}
+	return fields
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"hash/fnv"
+	"strconv"
+)
+
+// pickVariant returns a copy of the banner carrying the content of the variant
+// assigned to the user. The choice only depends on the banner, the user and the
+// variant weights, so a user keeps seeing the same variant.
+func pickVariant(banner *models.Banner, userID int64) *models.Banner {
+	if banner == nil || len(banner.Variants) == 0 {
+		return banner
+	}
+
+	var total int64
+	for _, variant := range banner.Variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return banner
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(strconv.FormatInt(banner.BannerID, 10) + ":" + strconv.FormatInt(userID, 10)))
+	point := int64(hash.Sum64() % uint64(total))
+
+	served := *banner
+	served.Variants = nil
+	for _, variant := range banner.Variants {
+		if point < variant.Weight {
+			served.Content = variant.Content
+			served.Variant = variant.Name
+			break
+		}
+		point -= variant.Weight
+	}
+
+	return &served
+}
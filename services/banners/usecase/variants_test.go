@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"avito-track/pkg/models"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func variantsBanner(weights map[string]int64) *models.Banner {
+	banner := &models.Banner{BannerID: 7, Content: json.RawMessage(`{"name":"base"}`)}
+	for _, name := range []string{"a", "b", "c"} {
+		weight, found := weights[name]
+		if !found {
+			continue
+		}
+		banner.Variants = append(banner.Variants, models.BannerVariant{
+			Name:    name,
+			Weight:  weight,
+			Content: json.RawMessage(`{"name":"` + name + `"}`),
+		})
+	}
+	return banner
+}
+
+func TestPickVariantWithoutVariants(t *testing.T) {
+	banner := variantsBanner(nil)
+	if served := pickVariant(banner, 1); served != banner {
+		t.Errorf("pickVariant() = %+v, want the banner itself", served)
+	}
+
+	if served := pickVariant(nil, 1); served != nil {
+		t.Errorf("pickVariant(nil) = %+v, want nil", served)
+	}
+}
+
+func TestPickVariantContent(t *testing.T) {
+	banner := variantsBanner(map[string]int64{"a": 1, "b": 1})
+
+	served := pickVariant(banner, 1)
+	if served.Variant == "" {
+		t.Fatal("pickVariant() served no variant")
+	}
+	if want := `{"name":"` + served.Variant + `"}`; string(served.Content) != want {
+		t.Errorf("content = %s, want %s", served.Content, want)
+	}
+	if served.Variants != nil {
+		t.Errorf("served banner lists variants %+v", served.Variants)
+	}
+	if len(banner.Variants) != 2 || string(banner.Content) != `{"name":"base"}` {
+		t.Errorf("pickVariant() changed the cached banner: %+v", banner)
+	}
+}
+
+func TestPickVariantStickiness(t *testing.T) {
+	banner := variantsBanner(map[string]int64{"a": 50, "b": 30, "c": 20})
+
+	for userID := int64(1); userID <= 1000; userID++ {
+		first := pickVariant(banner, userID).Variant
+		for i := 0; i < 3; i++ {
+			if again := pickVariant(banner, userID).Variant; again != first {
+				t.Fatalf("user %d got variant %q, then %q", userID, first, again)
+			}
+		}
+	}
+
+	// Another copy of the same banner, as after a cache refresh.
+	copied := variantsBanner(map[string]int64{"a": 50, "b": 30, "c": 20})
+	for userID := int64(1); userID <= 1000; userID++ {
+		if got, want := pickVariant(copied, userID).Variant, pickVariant(banner, userID).Variant; got != want {
+			t.Fatalf("user %d got variant %q from a copy, want %q", userID, got, want)
+		}
+	}
+}
+
+func TestPickVariantDistribution(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]int64
+	}{
+		{name: "even", weights: map[string]int64{"a": 1, "b": 1}},
+		{name: "70/30", weights: map[string]int64{"a": 70, "b": 30}},
+		{name: "three", weights: map[string]int64{"a": 50, "b": 30, "c": 20}},
+		{name: "single", weights: map[string]int64{"b": 5}},
+	}
+
+	const users = 20000
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			banner := variantsBanner(test.weights)
+
+			counts := make(map[string]int)
+			for userID := int64(1); userID <= users; userID++ {
+				counts[pickVariant(banner, userID).Variant]++
+			}
+
+			var total int64
+			for _, weight := range test.weights {
+				total += weight
+			}
+			for name, weight := range test.weights {
+				want := float64(weight) / float64(total)
+				got := float64(counts[name]) / users
+				if math.Abs(got-want) > 0.02 {
+					t.Errorf("variant %q served to %.3f of users, want %.3f", name, got, want)
+				}
+			}
+			for name := range counts {
+				if _, found := test.weights[name]; !found {
+					t.Errorf("unknown variant %q served", name)
+				}
+			}
+		})
+	}
+}